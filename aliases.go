@@ -0,0 +1,43 @@
+package main
+
+import "fmt"
+
+// Built-in aliases, computed from the configured versions
+const (
+	aliasLatest = "latest" // the numerically highest configured version
+	aliasOldest = "oldest" // the numerically lowest configured version
+)
+
+// resolveAlias returns the version an alias such as "stable" stands for, or
+// name itself if it isn't an alias. A configured version of the same name
+// wins over an alias, and an alias from the config wins over a built-in
+// one. An alias whose target isn't configured is an error.
+func resolveAlias(config *Config, name string) (string, error) {
+	if name == "" || config.Versions[name] != "" {
+		return name, nil
+	}
+	if target, ok := config.Aliases[name]; ok {
+		if config.Versions[target] == "" {
+			return "", fmt.Errorf("alias %s points at PHP %s, which is not configured", name, target)
+		}
+		return target, nil
+	}
+
+	switch name {
+	case aliasLatest, aliasOldest:
+		var numeric []string
+		for _, version := range configuredVersions(config) {
+			if _, ok := parseVersionParts(version); ok {
+				numeric = append(numeric, version)
+			}
+		}
+		if len(numeric) == 0 {
+			return "", fmt.Errorf("alias %s needs at least one numeric version configured", name)
+		}
+		if name == aliasOldest {
+			return numeric[0], nil
+		}
+		return numeric[len(numeric)-1], nil
+	}
+	return name, nil
+}
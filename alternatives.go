@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// alternativesLink is the symlink update-alternatives maintains for php on
+// Debian-based systems, pointing at the version the system has selected
+var alternativesLink = "/etc/alternatives/php"
+
+// alternativesNameRe extracts the version from a binary such as php8.2
+var alternativesNameRe = regexp.MustCompile(`^php(\d+\.\d+)$`)
+
+// alternativesTarget returns the binary the alternatives symlink finally
+// points at, following the whole chain, or "" if there is no such link
+func alternativesTarget() string {
+	if _, err := os.Lstat(alternativesLink); err != nil {
+		return ""
+	}
+	target, err := filepath.EvalSymlinks(alternativesLink)
+	if err != nil {
+		warnf("ignoring %s: %v", alternativesLink, err)
+		return ""
+	}
+	return target
+}
+
+// alternativesSource selects the version the system's alternatives point
+// php at: the configured version whose executable resolves to the same
+// binary or, failing that, the version in the binary's name
+func alternativesSource(cwd, searchDir string, config *Config) (string, error) {
+	target := alternativesTarget()
+	if target == "" {
+		return "", nil
+	}
+	for _, version := range configuredVersions(config) {
+		if path, err := filepath.EvalSymlinks(config.Versions[version]); err == nil && path == target {
+			verbosef("%s points at %s, configured as %s", alternativesLink, target, version)
+			return version, nil
+		}
+	}
+	if matches := alternativesNameRe.FindStringSubmatch(filepath.Base(target)); matches != nil {
+		verbosef("%s points at %s, named for %s", alternativesLink, target, matches[1])
+		return matches[1], nil
+	}
+	verbosef("%s points at %s, which has no version", alternativesLink, target)
+	return "", nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAlternativesSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		chain  []string // symlinks from the alternatives link on, ending at the binary
+		binary string   // what the chain ends at, under the temp root
+		want   string
+	}{
+		{name: "configured binary", chain: []string{"etc/alternatives/php"}, binary: "usr/bin/php8.2", want: "8.1"},
+		{name: "longer chain", chain: []string{"etc/alternatives/php", "usr/local/bin/php"}, binary: "usr/bin/php8.2", want: "8.1"},
+		{name: "named binary", chain: []string{"etc/alternatives/php"}, binary: "usr/bin/php8.3", want: "8.3"},
+		{name: "no version in name", chain: []string{"etc/alternatives/php"}, binary: "usr/bin/php-custom"},
+		{name: "dangling", chain: []string{"etc/alternatives/php"}, binary: "usr/bin/php7.4-removed"},
+		{name: "no link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			saved := alternativesLink
+			alternativesLink = filepath.Join(root, "etc", "alternatives", "php")
+			t.Cleanup(func() { alternativesLink = saved })
+
+			for _, name := range []string{"php8.2", "php8.3", "php-custom"} {
+				writeStub(t, filepath.Join(root, "usr", "bin", name), "exit 0")
+			}
+			// The config names 8.2's binary by another symlink, as "8.1" so
+			// only the resolved path can match it
+			configured := filepath.Join(root, "opt", "php")
+			os.MkdirAll(filepath.Dir(configured), 0755)
+			if err := os.Symlink(filepath.Join(root, "usr", "bin", "php8.2"), configured); err != nil {
+				t.Fatal(err)
+			}
+			config := newConfig()
+			config.Versions["8.1"] = configured
+			config.Versions["8.3"] = filepath.Join(root, "usr", "bin", "php8.3")
+
+			if tt.binary != "" {
+				next := filepath.Join(root, filepath.FromSlash(tt.binary))
+				for i := len(tt.chain) - 1; i >= 0; i-- {
+					link := filepath.Join(root, filepath.FromSlash(tt.chain[i]))
+					os.MkdirAll(filepath.Dir(link), 0755)
+					if err := os.Symlink(next, link); err != nil {
+						t.Fatal(err)
+					}
+					next = link
+				}
+			}
+
+			var version string
+			var err error
+			stderr := captureStderr(t, func() { version, err = alternativesSource(root, root, config) })
+			if err != nil || version != tt.want {
+				t.Errorf("alternativesSource = %q, %v, want %q", version, err, tt.want)
+			}
+			if warned := stderr != ""; warned != (tt.name == "dangling") {
+				t.Errorf("stderr = %q", stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchBoundary(t *testing.T) {
+	// Every kind of project file, written to a directory above the boundary
+	projectFiles := map[string]string{
+		versionFile:       "8.1\n",
+		".mise.toml":      "[tools]\nphp = \"8.1\"\n",
+		ideaProjectFile:   `<project><component name="PhpProjectSharedConfiguration" php_language_level="8.1" /></project>`,
+		ddevConfigFile:    "php_version: \"8.1\"\n",
+		"composer.json":   `{"type": "project", "require": {"php": "^8.1"}}`,
+		"composer.lock":   `{"platform-overrides": {"php": "8.1.2"}}`,
+		platformCheckFile: "if (!(PHP_VERSION_ID >= 80100)) {",
+		"artisan":         "",
+	}
+	finders := map[string]func(startDir string, bounded bool) string{
+		"php-version": func(dir string, bounded bool) string { _, path := findPhpVersionFile(dir, bounded); return path },
+		"mise":        func(dir string, bounded bool) string { _, path := findMiseVersion(dir, bounded); return path },
+		"idea":        func(dir string, bounded bool) string { _, path := findIdeaVersion(dir, bounded); return path },
+		"ddev":        func(dir string, bounded bool) string { _, path := findDdevVersion(dir, bounded); return path },
+		"composer":    func(dir string, bounded bool) string { path, _ := findComposerManifest(dir, bounded); return path },
+		"lock":        func(dir string, bounded bool) string { path, _ := findLockedPlatform(dir, bounded); return path },
+		"platform":    func(dir string, bounded bool) string { path, _ := findPlatformRequirement(dir, bounded); return path },
+		"type":        func(dir string, bounded bool) string { _, path := detectProjectType(dir, bounded); return path },
+		"workspace": func(dir string, bounded bool) string {
+			if root := workspaceRoot(dir, bounded); root != dir {
+				return root
+			}
+			return ""
+		},
+	}
+
+	tests := []struct {
+		name    string
+		inHome  bool // the files are in the home directory rather than above a .git
+		bounded bool
+		found   bool
+	}{
+		{name: "above the repository, bounded", bounded: true},
+		{name: "above the repository, unbounded", found: true},
+		{name: "in the home directory, bounded", inHome: true, bounded: true},
+		{name: "in the home directory, unbounded", inHome: true, found: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			outer := t.TempDir()
+			for name, content := range projectFiles {
+				writeFile(t, filepath.Join(outer, name), content)
+			}
+			project := filepath.Join(outer, "project")
+			if tt.inHome {
+				t.Setenv("HOME", outer)
+			} else if err := os.MkdirAll(filepath.Join(project, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			start := filepath.Join(project, "src")
+			if err := os.MkdirAll(start, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, find := range finders {
+				if got := find(start, tt.bounded); (got != "") != tt.found {
+					t.Errorf("%s found %q, want found: %v", name, got, tt.found)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveByPath(t *testing.T) {
+	config := newConfig()
+	config.Versions["8.1"] = filepath.FromSlash("/opt/php81/bin/php")
+	config.Versions["8.2"] = filepath.FromSlash("/opt/php82/bin/php")
+	config.Versions["8.3"] = filepath.FromSlash("/usr/local/php/8.3/bin/php")
+
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{prefix: "/opt/php82", want: "8.2"},
+		{prefix: "/opt/php82/", want: "8.2"},
+		{prefix: "/opt/php82/bin/php", want: "8.2"},
+		{prefix: "/opt/php81/../php81", want: "8.1"},
+		{prefix: "/usr/local/php", want: "8.3"},
+		// Prefixes match whole path components
+		{prefix: "/opt/php8", wantErr: "no configured PHP executable is under"},
+		{prefix: "/srv/php", wantErr: "no configured PHP executable is under"},
+		{prefix: "/opt", wantErr: "several configured versions are under " + filepath.FromSlash("/opt") + " (8.1, 8.2)"},
+	}
+	for _, tt := range tests {
+		resolution, err := resolveByPath(config, filepath.FromSlash(tt.prefix))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolveByPath(%s) error = %v, want %q", tt.prefix, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || resolution.Version != tt.want || resolution.Source != sourceByPath {
+			t.Errorf("resolveByPath(%s) = %+v, %v, want %s", tt.prefix, resolution, err, tt.want)
+		}
+	}
+}
+
+func TestByPathFlag(t *testing.T) {
+	tests := []struct {
+		prefix   string // under the temp root
+		want     string
+		wantCode int
+	}{
+		{prefix: "php82", want: "ran 8.2\n"},
+		{prefix: "php8", wantCode: exitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php81", "bin", "php"), "echo ran 8.1") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php82", "bin", "php"), "echo ran 8.2") + "\n"
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			// The pin is overridden
+			writeFile(t, filepath.Join(root, versionFile), "8.1\n")
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, "--by-path", filepath.Join(root, tt.prefix), "x.php")
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if tt.want != "" && stdout != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
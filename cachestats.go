@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cacheStatsName is the file next to the config counting cache hits and
+// misses, for --resolve-cache-stats
+const cacheStatsName = ".php-runner-cache-stats"
+
+// countCacheLookup records a hit or miss of the named cache. Runs racing
+// to update the counters may lose a count, which is harmless for a
+// statistic; errors are ignored for the same reason.
+func countCacheLookup(cache string, hit bool) {
+	statsPath := stateFile(cacheStatsName)
+	if statsPath == "" {
+		return
+	}
+	key := cache + " misses"
+	if hit {
+		key = cache + " hits"
+	}
+	stats, order := readCacheStats(statsPath)
+	if _, ok := stats[key]; !ok {
+		order = append(order, key)
+	}
+	stats[key]++
+
+	var content strings.Builder
+	for _, key := range order {
+		fmt.Fprintf(&content, "%s %d\n", key, stats[key])
+	}
+	writeFileAtomic(statsPath, []byte(content.String()), 0644)
+}
+
+// readCacheStats reads the counters, one per line as "<cache> <hits|misses>
+// <count>", returning them with their keys in file order. A missing or
+// damaged file counts as zero.
+func readCacheStats(statsPath string) (map[string]int, []string) {
+	stats := make(map[string]int)
+	var order []string
+	content, err := os.ReadFile(statsPath)
+	if err != nil {
+		return stats, order
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			continue
+		}
+		count, err := strconv.Atoi(line[i+1:])
+		if err != nil {
+			continue
+		}
+		if _, ok := stats[line[:i]]; !ok {
+			order = append(order, line[:i])
+		}
+		stats[line[:i]] = count
+	}
+	return stats, order
+}
+
+// showCacheStats prints the hit and miss counts of each cache with where the
+// cache is kept, or with reset zeroes them
+func showCacheStats(reset bool) int {
+	statsPath := stateFile(cacheStatsName)
+	if statsPath == "" {
+		fmt.Println("No caches are kept with the built-in config")
+		return 0
+	}
+	if reset {
+		if err := os.Remove(statsPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Cache counters reset (%s)\n", statsPath)
+		return 0
+	}
+
+	stats, _ := readCacheStats(statsPath)
+	hits, misses := stats["modules hits"], stats["modules misses"]
+	fmt.Printf("Module cache: %s\n", stateFile(moduleCacheName))
+	fmt.Printf("  hits:     %d\n", hits)
+	fmt.Printf("  misses:   %d\n", misses)
+	if hits+misses > 0 {
+		fmt.Printf("  hit rate: %d%%\n", hits*100/(hits+misses))
+	}
+	fmt.Printf("Counters kept in %s\n", statsPath)
+	return 0
+}
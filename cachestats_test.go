@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCountCacheLookup(t *testing.T) {
+	tests := []struct {
+		name       string
+		lookups    []bool // hit or miss, in order
+		wantHits   int
+		wantMisses int
+	}{
+		{name: "none"},
+		{name: "misses only", lookups: []bool{false, false}, wantMisses: 2},
+		{name: "miss then hits", lookups: []bool{false, true, true}, wantHits: 2, wantMisses: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			configDir = t.TempDir()
+			for _, hit := range tt.lookups {
+				countCacheLookup("modules", hit)
+			}
+			stats, _ := readCacheStats(stateFile(cacheStatsName))
+			if stats["modules hits"] != tt.wantHits || stats["modules misses"] != tt.wantMisses {
+				t.Errorf("hits, misses = %d, %d, want %d, %d", stats["modules hits"], stats["modules misses"], tt.wantHits, tt.wantMisses)
+			}
+		})
+	}
+}
+
+func TestResolveCacheStatsFlag(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	php := writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+	configPath := writeFile(t, filepath.Join(root, configFileName), "8.2: "+php+"\n")
+	writeFile(t, filepath.Join(root, cacheStatsName), "modules hits 3\nmodules misses 1\n")
+	env := []string{"PHP_RUNNER_CONFIG=" + configPath}
+
+	tests := []struct {
+		args     []string
+		wantCode int
+		want     []string // substrings of stdout, or of stderr on failure
+	}{
+		{[]string{"--resolve-cache-stats"}, 0, []string{"hits:     3", "misses:   1", "hit rate: 75%"}},
+		{[]string{"--reset"}, exitUsage, []string{"--reset only applies to --resolve-cache-stats"}},
+		{[]string{"--resolve-cache-stats=reset"}, exitUsage, []string{"does not take a value"}},
+		{[]string{"--resolve-cache-stats", "--reset"}, 0, []string{"Cache counters reset"}},
+		{[]string{"--resolve-cache-stats"}, 0, []string{"hits:     0", "misses:   0"}},
+	}
+	// The cases run in order: the reset clears the counters the last one reads
+	for _, tt := range tests {
+		stdout, stderr, code := runRunner(t, root, env, tt.args...)
+		if code != tt.wantCode {
+			t.Errorf("%s exited %d, want %d: %s", tt.args, code, tt.wantCode, stderr)
+		}
+		output := stdout
+		if tt.wantCode != 0 {
+			output = stderr
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s printed %q, want it to contain %q", tt.args, output, want)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, cacheStatsName)); !os.IsNotExist(err) {
+		t.Errorf("counters file still exists after --reset: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// checksumLineRe matches the config's top-level "checksum: <value>" line,
+// which is left out of the checksum it records
+var checksumLineRe = regexp.MustCompile(`(?m)^checksum:[ \t]*(\S*)[ \t]*(?:\r?\n|$)`)
+
+// configChecksum returns the checksum of a config file's content, excluding
+// its checksum line, in the form the checksum setting records
+func configChecksum(content []byte) string {
+	sum := sha256.Sum256(checksumLineRe.ReplaceAll(content, nil))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// verifyConfigChecksum returns an error unless the config file has a
+// checksum line matching the rest of its content, for --verify-checksum
+func verifyConfigChecksum(configPath string) error {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("cannot open config file: %v", err)
+	}
+	actual := configChecksum(content)
+	match := checksumLineRe.FindSubmatch(content)
+	if match == nil {
+		return fmt.Errorf("%s has no checksum to verify; its content hashes to %s", configPath, actual)
+	}
+	if recorded := string(match[1]); recorded != actual {
+		return fmt.Errorf("%s has been modified: it records checksum %s but hashes to %s", configPath, recorded, actual)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigChecksum(t *testing.T) {
+	base := configChecksum([]byte("8.2: /usr/bin/php8.2\n8.3: /usr/bin/php8.3\n"))
+	tests := []struct {
+		name    string
+		content string
+		same    bool // whether it hashes as the base content does
+	}{
+		{name: "checksum first", content: "checksum: sha256:abc\n8.2: /usr/bin/php8.2\n8.3: /usr/bin/php8.3\n", same: true},
+		{name: "checksum between", content: "8.2: /usr/bin/php8.2\nchecksum: sha256:abc\n8.3: /usr/bin/php8.3\n", same: true},
+		{name: "checksum last without a newline", content: "8.2: /usr/bin/php8.2\n8.3: /usr/bin/php8.3\nchecksum: sha256:abc", same: true},
+		{name: "changed path", content: "8.2: /usr/bin/php8.2\n8.3: /opt/php8.3\n"},
+		{name: "added comment", content: "# reviewed\n8.2: /usr/bin/php8.2\n8.3: /usr/bin/php8.3\n"},
+		// Only a top-level checksum line is left out
+		{name: "indented checksum", content: "8.2: /usr/bin/php8.2\n  checksum: sha256:abc\n8.3: /usr/bin/php8.3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := configChecksum([]byte(tt.content)) == base; same != tt.same {
+				t.Errorf("same checksum = %v, want %v", same, tt.same)
+			}
+		})
+	}
+	if !strings.HasPrefix(base, "sha256:") || len(base) != len("sha256:")+64 {
+		t.Errorf("checksum = %q, want sha256: and 64 hex digits", base)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(config, checksum string) string // the config file given its checksum line
+		flag   bool
+		errMsg string
+	}{
+		{
+			name: "matching",
+			edit: func(config, checksum string) string { return config + checksum },
+			flag: true,
+		},
+		{
+			name: "matching with the checksum first",
+			edit: func(config, checksum string) string { return checksum + config },
+			flag: true,
+		},
+		{
+			name: "tampered",
+			edit: func(config, checksum string) string {
+				return strings.Replace(config, "php8.2", "php8.2-evil", 1) + checksum
+			},
+			flag:   true,
+			errMsg: "has been modified: it records checksum sha256:",
+		},
+		{
+			name:   "no checksum",
+			edit:   func(config, checksum string) string { return config },
+			flag:   true,
+			errMsg: "has no checksum to verify; its content hashes to sha256:",
+		},
+		{
+			name: "tampered without the flag",
+			edit: func(config, checksum string) string {
+				return strings.Replace(config, "8.2: ", "8.2:  ", 1) + checksum
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "echo ran") + "\n"
+			writeStub(t, filepath.Join(root, "php8.2-evil"), "echo evil")
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			env := []string{"PHP_RUNNER_CONFIG=" + configPath}
+
+			stdout, stderr, code := runRunner(t, root, env, "config", "checksum")
+			checksum, found := strings.CutPrefix(stdout, configPath+": ")
+			if code != 0 || !found || !strings.HasPrefix(checksum, "checksum: sha256:") {
+				t.Fatalf("config checksum exited %d with %q: %s", code, stdout, stderr)
+			}
+			writeFile(t, configPath, tt.edit(config, checksum))
+
+			var args []string
+			if tt.flag {
+				args = append(args, "--verify-checksum")
+			}
+			stdout, stderr, code = runRunner(t, root, env, append(args, "x.php")...)
+			if tt.errMsg != "" {
+				if code != exitConfig || !strings.Contains(stderr, configPath+" "+tt.errMsg) {
+					t.Errorf("exited %d with %q, want %d and %q", code, stderr, exitConfig, tt.errMsg)
+				}
+				if strings.Contains(stdout, "evil") {
+					t.Error("the tampered config was used")
+				}
+				return
+			}
+			if code != 0 || stdout != "ran\n" {
+				t.Errorf("exited %d with %q: %s", code, stdout, stderr)
+			}
+		})
+	}
+}
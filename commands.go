@@ -0,0 +1,1053 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// runnerEnvVars lists the PHP_RUNNER_* variables php-runner reads, so they
+// are reported by the env command even when unset
+var runnerEnvVars = []string{
+	"PHP_RUNNER_CONFIG",
+	"PHP_RUNNER_FILE_MODE",
+	"PHP_RUNNER_PIN",
+	"PHP_RUNNER_POLICY",
+	"PHP_RUNNER_VERBOSE",
+	"PHP_RUNNER_VERSION",
+}
+
+// subcommand returns the handler for a php-runner subcommand, or nil if name
+// is not one and should be passed through to PHP
+func subcommand(name string) func(args []string) int {
+	switch name {
+	case "env":
+		return envCommand
+	case "config":
+		return configCommand
+	case "list":
+		return listCommand
+	case "which":
+		return whichCommand
+	case "current":
+		return currentCommand
+	case "ext-diff":
+		return extDiffCommand
+	case "upgrade-check":
+		return upgradeCheckCommand
+	case "test-all":
+		return testAllCommand
+	case "cross-check":
+		return crossCheckCommand
+	case "export-env":
+		return exportEnvCommand
+	case "check-composer":
+		return checkComposerCommand
+	case "pin-all":
+		return pinAllCommand
+	case "matching":
+		return matchingCommand
+	case "doctor":
+		return doctorCommand
+	case "completion":
+		return completionCommand
+	case "use":
+		return useCommand
+	}
+	return nil
+}
+
+// envCommand prints every environment variable php-runner consults as
+// KEY=VALUE lines, marking the ones that are not set
+func envCommand(args []string) int {
+	names := append([]string{}, runnerEnvVars...)
+	var extra []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(name, "PHP_RUNNER_") && !slices.Contains(names, name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	names = append(names, extra...)
+	names = append(names, configEnvVars()...)
+
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			fmt.Printf("%s=%s\n", name, value)
+		} else {
+			fmt.Printf("%s= (unset)\n", name)
+		}
+	}
+
+	// The php found on PATH is used for version detection
+	if phpPath, err := exec.LookPath("php"); err == nil {
+		fmt.Printf("PHP_RUNNER_PATH_PHP=%s\n", phpPath)
+	} else {
+		fmt.Printf("PHP_RUNNER_PATH_PHP= (not found)\n")
+	}
+	if target := alternativesTarget(); target != "" {
+		fmt.Printf("PHP_RUNNER_ALTERNATIVES_PHP=%s\n", target)
+	}
+	return 0
+}
+
+// exportEnvCommand prints a PHP_RUNNER_V_<version>=<path> line for every
+// configured version, so tooling can find each PHP without parsing the config
+func exportEnvCommand(args []string) int {
+	flags := flag.NewFlagSet("export-env", flag.ContinueOnError)
+	shellEscape := flags.Bool("shell-escape", false, "quote the values for POSIX shells")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	// Different keys can map to the same name, e.g. "8.2" and "8_2"; check
+	// them all before printing anything
+	versions := configuredVersions(config)
+	versionsByName := make(map[string]string)
+	for _, version := range versions {
+		name := versionEnvName(version)
+		if other, taken := versionsByName[name]; taken {
+			fmt.Fprintf(os.Stderr, "Error: versions %s and %s would both be exported as %s\n", other, version, name)
+			return 1
+		}
+		versionsByName[name] = version
+	}
+
+	for _, version := range versions {
+		value := config.Versions[version]
+		if *shellEscape {
+			value = shellQuote(value)
+		}
+		fmt.Printf("%s=%s\n", versionEnvName(version), value)
+	}
+	return 0
+}
+
+// versionEnvName returns the variable export-env uses for a version key:
+// letters are upper-cased and anything else that isn't a letter or digit
+// becomes an underscore, so "8.2-zts" is PHP_RUNNER_V_8_2_ZTS
+func versionEnvName(version string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, version)
+	return "PHP_RUNNER_V_" + name
+}
+
+// configEnvVars returns the variables findConfigFiles uses to build its search paths
+func configEnvVars() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"USERPROFILE", "APPDATA", "PROGRAMDATA"}
+	}
+	return []string{"XDG_CONFIG_HOME", "HOME"}
+}
+
+// configCommand dispatches the "config" subcommands
+func configCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner config <validate|lint|checksum>")
+		return 2
+	}
+	switch args[0] {
+	case "validate":
+		return configValidateCommand(args[1:])
+	case "lint":
+		return configLintCommand(args[1:])
+	case "checksum":
+		return configChecksumCommand(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "Unknown config command: %s\n", args[0])
+	return 2
+}
+
+// configValidateCommand checks every config file for problems the loader
+// would otherwise hide, such as a version defined more than once
+func configValidateCommand(args []string) int {
+	configPaths, err := findConfigFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding config file: %v\n", err)
+		return 1
+	}
+
+	status := 0
+	for _, configPath := range configPaths {
+		if !validateConfigFile(configPath) {
+			status = 1
+		}
+	}
+	return status
+}
+
+// validateConfigFile prints the problems found in one config file, each
+// prefixed with its path, and reports whether there were none
+func validateConfigFile(configPath string) bool {
+	entries, err := readConfigEntries(configPath)
+	if err != nil {
+		fmt.Printf("%s: %v\n", configPath, err)
+		return false
+	}
+
+	duplicates := findDuplicateEntries(entries)
+	// Nested YAML keeps only the last of a repeated key, so the entries
+	// can't show the others; find them in the file itself
+	if content, err := os.ReadFile(configPath); err == nil && isStructuredConfig(content) {
+		duplicates = findStructuredDuplicates(content)
+	}
+	for _, dup := range duplicates {
+		lines := make([]string, len(dup.Lines))
+		for i, line := range dup.Lines {
+			lines[i] = strconv.Itoa(line)
+		}
+		fmt.Printf("%s: %s is defined more than once (lines %s); line %d wins\n",
+			configPath, dup.Key, strings.Join(lines, ", "), dup.Lines[len(dup.Lines)-1])
+	}
+	if len(duplicates) > 0 {
+		return false
+	}
+
+	fmt.Printf("%s: OK\n", configPath)
+	return true
+}
+
+// configChecksumCommand prints each config file's checksum, ready to be
+// recorded in it as a checksum line for --verify-checksum
+func configChecksumCommand(args []string) int {
+	configPaths, err := findConfigFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding config file: %v\n", err)
+		return 1
+	}
+	status := 0
+	for _, configPath := range configPaths {
+		content, err := os.ReadFile(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: checksum: %s\n", configPath, configChecksum(content))
+	}
+	return status
+}
+
+// duplicateEntry records every line a repeated config key appears on
+type duplicateEntry struct {
+	Key   string
+	Lines []int
+}
+
+// repeatableSettings may appear on several lines, each adding to the last
+var repeatableSettings = map[string]bool{"rule": true, "env": true, "args": true}
+
+// findDuplicateEntries returns the keys that appear more than once, in
+// order of first appearance
+func findDuplicateEntries(entries []configEntry) []duplicateEntry {
+	lines := make(map[string][]int)
+	var order []string
+	for _, entry := range entries {
+		if setting, _, _ := strings.Cut(entry.Key, "."); repeatableSettings[setting] {
+			continue
+		}
+		if _, seen := lines[entry.Key]; !seen {
+			order = append(order, entry.Key)
+		}
+		lines[entry.Key] = append(lines[entry.Key], entry.Line)
+	}
+
+	var duplicates []duplicateEntry
+	for _, key := range order {
+		if len(lines[key]) > 1 {
+			duplicates = append(duplicates, duplicateEntry{Key: key, Lines: lines[key]})
+		}
+	}
+	return duplicates
+}
+
+// versionKeyFormRe is the form lint expects of a version key: major.minor
+// with an optional patch and suffix, e.g. "8.2", "8.2.10" or "8.2-zts"
+var versionKeyFormRe = regexp.MustCompile(`^\d+\.\d+(\.\d+)?([-+][0-9A-Za-z.-]+)?$`)
+
+// configLintCommand reports the entries of every config file that load fine
+// but look like mistakes. It is advisory and exits 0 unless --strict is given.
+func configLintCommand(args []string) int {
+	flags := flag.NewFlagSet("config lint", flag.ContinueOnError)
+	strict := flags.Bool("strict", false, "exit non-zero if anything is reported")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	configPaths, err := findConfigFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding config file: %v\n", err)
+		return 1
+	}
+
+	status, reported := 0, false
+	for _, configPath := range configPaths {
+		entries, err := readConfigEntries(configPath)
+		if err != nil {
+			fmt.Printf("%s: %v\n", configPath, err)
+			status = 1
+			continue
+		}
+
+		warnings := lintConfigEntries(entries)
+		for _, warning := range warnings {
+			fmt.Printf("%s:%s\n", configPath, warning)
+		}
+		if len(warnings) == 0 {
+			fmt.Printf("%s: no problems found\n", configPath)
+		}
+		reported = reported || len(warnings) > 0
+	}
+	if *strict && reported {
+		return 1
+	}
+	return status
+}
+
+// lintConfigEntries returns a "<line>: <problem>" message for each
+// suspicious version entry
+func lintConfigEntries(entries []configEntry) []string {
+	var warnings []string
+	report := func(entry configEntry, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf("%d: ", entry.Line)+fmt.Sprintf(format, args...))
+	}
+
+	versionsByPath := make(map[string]string)
+	scratch := newConfig()
+	for _, entry := range entries {
+		if isSetting, _ := applySetting(scratch, entry); isSetting {
+			continue
+		}
+		version, path := entry.Key, expandPath(entry.Value)
+
+		if !versionKeyFormRe.MatchString(version) {
+			report(entry, "version %q is not of the form major.minor[.patch]", version)
+		}
+		if _, _, isContainer := parseContainerPath(path); isContainer {
+			continue
+		}
+		path = relativeTo(entry.Base, path)
+
+		name := strings.ToLower(filepath.Base(path))
+		if !strings.HasPrefix(strings.TrimSuffix(name, ".exe"), "php") {
+			report(entry, "%s doesn't look like a PHP executable", path)
+		}
+		if other, ok := versionsByPath[filepath.Clean(path)]; ok {
+			report(entry, "%s is also configured as version %s", path, other)
+		} else {
+			versionsByPath[filepath.Clean(path)] = version
+		}
+		if !underInstallRoot(path) {
+			report(entry, "%s is outside the usual install locations", path)
+		}
+	}
+	return warnings
+}
+
+// installRoots are the directories PHP is usually installed under, per
+// platform; the home directory is added for user-level managers
+var installRoots = map[string][]string{
+	"linux":   {"/usr", "/opt", "/nix", "/snap"},
+	"darwin":  {"/usr", "/opt", "/Applications", "/nix"},
+	"windows": {`C:\php`, `C:\tools`, `C:\Program Files`, `C:\Program Files (x86)`, `C:\xampp`, `C:\laragon`, `C:\wamp64`, `C:\dev`},
+}
+
+// underInstallRoot reports whether path is inside one of the usual install
+// locations; relative paths never are
+func underInstallRoot(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	roots := installRoots[runtime.GOOS]
+	if home, err := os.UserHomeDir(); err == nil {
+		roots = append(roots, home)
+	}
+	for _, root := range roots {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") && strings.EqualFold(filepath.VolumeName(root), filepath.VolumeName(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// listCommand prints every configured version with its path in version
+// order, including the ones skipped because their executable is missing,
+// and marks the version that would run in the current directory
+func listCommand(args []string) int {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	packages := flags.Bool("packages", false, "show the system package that installed each version")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner list [--packages]")
+		return 2
+	}
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	// The listing is still useful when the current directory is gone or
+	// resolution fails, just without a selection
+	var selected Resolution
+	if cwd, err := workingDir(); err != nil {
+		warnf("cannot tell which version is selected: %v", err)
+	} else if selected, err = resolveVersion(cwd, config); err != nil {
+		warnf("cannot tell which version is selected: %v", err)
+	}
+
+	versions := configuredVersions(config)
+	for version := range config.Missing {
+		if _, ok := config.Versions[version]; !ok {
+			versions = append(versions, version)
+		}
+	}
+	sortVersions(versions)
+
+	for _, version := range versions {
+		path, ok := config.Versions[version]
+		var owner string
+		if ok && *packages {
+			// Querying the package manager spawns a process per version
+			if owner = packageOwner(path); owner != "" {
+				owner = " [" + owner + "]"
+			}
+		}
+		switch {
+		case !ok:
+			status, _ := executableStatus(config.Missing[version])
+			fmt.Printf("  %-8s %s (%s, skipped)\n", version, config.Missing[version], status)
+		case version == selected.Version:
+			fmt.Printf("* %-8s %s%s (selected by %s)\n", version, path, owner, selected.Source)
+		default:
+			fmt.Printf("  %-8s %s%s\n", version, path, owner)
+		}
+	}
+	return 0
+}
+
+// whichCommand prints the absolute path of the PHP executable that would run
+// in the current directory
+func whichCommand(args []string) int {
+	return printResolution("which", args, func(r Resolution) string { return absoluteExecutable(r.Path) })
+}
+
+// absoluteExecutable makes a configured path absolute so the output of which
+// works from any directory. Container images are returned as configured.
+func absoluteExecutable(path string) string {
+	if _, _, isContainer := parseContainerPath(path); isContainer {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// currentCommand prints the PHP version that would run in the current directory
+func currentCommand(args []string) int {
+	return printResolution("current", args, func(r Resolution) string { return r.Version })
+}
+
+// printResolution resolves the version for the current directory without
+// writing a .php-version file and prints the chosen field of the result
+func printResolution(name string, args []string, field func(Resolution) string) int {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	shellEscape := flags.Bool("shell-escape", false, "quote the output for POSIX shells")
+	templateText := flags.String("template", "", "format the output with a Go template, e.g. '{{.Version}} at {{.Path}}'")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Check the template before doing any work
+	var tmpl *template.Template
+	if *templateText != "" {
+		var err error
+		if tmpl, err = template.New(name).Option("missingkey=error").Parse(*templateText); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --template: %v\n", err)
+			return 2
+		}
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	resolution, err := resolveVersion(cwd, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	value := field(resolution)
+	if tmpl != nil {
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, resolution); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --template: %v\n", err)
+			return 2
+		}
+		value = rendered.String()
+	}
+	if *shellEscape {
+		value = shellQuote(value)
+	}
+	fmt.Println(value)
+	return 0
+}
+
+// extDiffCommand compares the extensions loaded by two configured versions
+func extDiffCommand(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner ext-diff <from-version> <to-version>")
+		return 2
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	var modules [2][]string
+	for i, version := range args {
+		phpPath, ok := config.Versions[version]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "PHP version %s not found in configuration\n", version)
+			return 1
+		}
+		if _, _, isContainer := parseContainerPath(phpPath); isContainer {
+			fmt.Fprintf(os.Stderr, "Cannot list extensions of container image %s\n", phpPath)
+			return 1
+		}
+		if modules[i], err = cachedModules(phpPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	added, removed := diffModules(modules[0], modules[1])
+	for _, name := range added {
+		fmt.Printf("+ %s\n", name)
+	}
+	for _, name := range removed {
+		fmt.Printf("- %s\n", name)
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		fmt.Printf("PHP %s and %s load the same extensions\n", args[0], args[1])
+	}
+	return 0
+}
+
+// diffModules returns the modules only in to (added) and only in from
+// (removed), compared case-insensitively and sorted
+func diffModules(from, to []string) ([]string, []string) {
+	inFrom := make(map[string]bool)
+	for _, name := range from {
+		inFrom[strings.ToLower(name)] = true
+	}
+	inTo := make(map[string]bool)
+	for _, name := range to {
+		inTo[strings.ToLower(name)] = true
+	}
+
+	var added, removed []string
+	for _, name := range to {
+		if !inFrom[strings.ToLower(name)] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range from {
+		if !inTo[strings.ToLower(name)] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// upgradeCheckCommand reports how many minor releases an upgrade jumps and
+// advises upgrading one minor version at a time when it is more than one
+func upgradeCheckCommand(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner upgrade-check <from-version> <to-version>")
+		return 2
+	}
+
+	var parsed [2][]int
+	for i, version := range args {
+		parts, ok := parseVersionParts(version)
+		if !ok || len(parts) < 2 {
+			fmt.Fprintf(os.Stderr, "Invalid version %q: expected major.minor such as 8.2\n", version)
+			return 2
+		}
+		parsed[i] = parts
+	}
+
+	path, jumpedFrom := upgradePath(parsed[0], parsed[1])
+	atLeast := ""
+	if jumpedFrom != "" {
+		atLeast = "at least "
+	}
+	switch {
+	case len(path) == 0:
+		fmt.Printf("%s is not newer than %s; nothing to upgrade\n", args[1], args[0])
+	case len(path) == 1:
+		fmt.Printf("Upgrading from %s to %s jumps %s1 minor version\n", args[0], args[1], atLeast)
+	default:
+		fmt.Printf("Upgrading from %s to %s jumps %s%d minor versions\n", args[0], args[1], atLeast, len(path))
+		steps := append([]string{formatVersionParts(parsed[0][:2])}, path...)
+		fmt.Printf("Warning: consider upgrading one minor version at a time: %s\n", strings.Join(steps, " -> "))
+	}
+	if jumpedFrom != "" {
+		major, _, _ := strings.Cut(jumpedFrom, ".")
+		fmt.Printf("Note: the last release of PHP %s isn't known yet, so any between %s and the next major version aren't counted\n", major, jumpedFrom)
+	}
+	return 0
+}
+
+// showDeprecations lists every version the config marks as deprecated
+func showDeprecations(config *Config) int {
+	var versions []string
+	for version := range config.Deprecations {
+		versions = append(versions, version)
+	}
+	sortVersions(versions)
+
+	if len(versions) == 0 {
+		fmt.Println("No configured versions are deprecated")
+	}
+	for _, version := range versions {
+		fmt.Printf("%s: %s\n", version, config.Deprecations[version])
+	}
+	return 0
+}
+
+// skippedComposerDirs are not searched by check-composer: installed
+// dependencies declare their own requirements, which Composer has already
+// checked against the project's
+var skippedComposerDirs = map[string]bool{"vendor": true, "node_modules": true, ".git": true}
+
+// matchingCommand prints the configured versions satisfying a constraint
+// such as "^8.1", lowest first, and fails if none does
+func matchingCommand(args []string) int {
+	flags := flag.NewFlagSet("matching", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the versions and their paths as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner matching [--json] <constraint>")
+		return 2
+	}
+	constraint, err := parseConstraint(strings.Join(flags.Args(), " "))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 2
+	}
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	matches := matchingVersions(config, constraint)
+	if *asJSON {
+		type match struct {
+			Version string `json:"version"`
+			Path    string `json:"path"`
+		}
+		list := make([]match, 0, len(matches))
+		for _, version := range matches {
+			list = append(list, match{version, config.Versions[version]})
+		}
+		encoded, _ := json.MarshalIndent(list, "", "  ")
+		fmt.Println(string(encoded))
+	} else {
+		for _, version := range matches {
+			fmt.Println(version)
+		}
+	}
+	if len(matches) == 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkComposerCommand finds every composer.json under a directory and
+// reports those whose PHP requirement no configured version satisfies
+func checkComposerCommand(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner check-composer [<dir>]")
+		return 2
+	}
+	root := "."
+	if len(args) == 1 {
+		root = args[0]
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	checked, failed, malformed := 0, 0, 0
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && skippedComposerDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() != "composer.json" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var manifest composerManifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			// Its requirement can't be known, so it isn't counted as checked
+			malformed++
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			return nil
+		}
+		requirement := manifest.Require["php"]
+		if requirement == "" {
+			return nil
+		}
+
+		checked++
+		constraint, err := parseConstraint(requirement)
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			return nil
+		}
+		if matches := matchingVersions(config, constraint); len(matches) > 0 {
+			fmt.Printf("ok   %s: %s (%s)\n", path, requirement, strings.Join(matches, ", "))
+		} else {
+			failed++
+			fmt.Printf("FAIL %s: %s is not satisfied by any configured version\n", path, requirement)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("%d of %d manifests with a PHP requirement can't be satisfied (configured: %s)\n",
+		failed, checked, strings.Join(configuredVersions(config), ", "))
+	if malformed > 0 {
+		fmt.Printf("%d manifests couldn't be parsed\n", malformed)
+	}
+	if failed > 0 || malformed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// pinAllCommand writes a .php-version into every directory under a root
+// that has a composer.json, pinning the given version or, without one, the
+// highest configured version each project's PHP requirement allows.
+// Existing pins are left alone unless --force is given.
+func pinAllCommand(args []string) int {
+	flags := flag.NewFlagSet("pin-all", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print the pins that would be written without writing them")
+	force := flags.Bool("force", false, "replace existing .php-version files")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner pin-all [--dry-run] [--force] <dir> [<version>]")
+		return 2
+	}
+	root, version := flags.Arg(0), flags.Arg(1)
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+	if version != "" && config.Versions[version] == "" {
+		fmt.Fprintf(os.Stderr, "Error: version %s is not configured (available: %s)\n", version, strings.Join(configuredVersions(config), ", "))
+		return 1
+	}
+	mode := pinFileMode(config)
+
+	failed := 0
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && skippedComposerDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() != "composer.json" {
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+		versionPath := filepath.Join(dir, versionFile)
+		if existing := readVersionFile(versionPath); existing != "" && !*force {
+			fmt.Printf("skip %s: already pinned to %s\n", dir, existing)
+			return nil
+		}
+		pin := version
+		if pin == "" {
+			if pin, err = projectBestMatch(config, path); err != nil {
+				failed++
+				fmt.Printf("FAIL %s: %v\n", dir, err)
+				return nil
+			}
+			if pin == "" {
+				fmt.Printf("skip %s: composer.json has no PHP requirement\n", dir)
+				return nil
+			}
+		}
+
+		if *dryRun {
+			fmt.Printf("would pin %s to %s\n", dir, pin)
+			return nil
+		}
+		if err := writeVersionFile(versionPath, pin, mode); err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %v\n", dir, err)
+			return nil
+		}
+		fmt.Printf("pinned %s to %s\n", dir, pin)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// useCommand pins a configured version, or an alias for one, in the current
+// directory's .php-version. With --global it is instead saved for directories
+// that pin nothing, ahead of the php on PATH and the default.
+func useCommand(args []string) int {
+	flags := flag.NewFlagSet("use", flag.ContinueOnError)
+	global := flags.Bool("global", false, "save the version for every directory without a pin instead of pinning the current directory")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner use [--global] <version>")
+		return 2
+	}
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	name := flags.Arg(0)
+	version, err := resolveAlias(config, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if config.Versions[version] == "" {
+		fmt.Fprintf(os.Stderr, "Error: PHP %s is not configured (available: %s)\n", name, strings.Join(configuredVersions(config), ", "))
+		return 1
+	}
+
+	if *global {
+		// The saved default holds a version key, so aliases are resolved
+		statePath := stateFile(globalStateName)
+		if statePath == "" {
+			fmt.Fprintln(os.Stderr, "Error: the built-in config is in use, so there is nowhere to save a global version")
+			return 1
+		}
+		if err := writeFileAtomic(statePath, []byte(version+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Saved PHP %s as the global version in %s\n", version, statePath)
+		if order := sourceOrder(config); !slices.Contains(order, sourceGlobal) {
+			warnf("the global source isn't in the sources setting (%s), so the saved version won't be used", strings.Join(order, ", "))
+		}
+		return 0
+	}
+
+	cwd, err := workingDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+	versionPath := filepath.Join(cwd, versionFile)
+	if err := writeVersionFile(versionPath, name, pinFileMode(config)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Pinned PHP %s in %s\n", name, versionPath)
+	return 0
+}
+
+// projectBestMatch returns the highest configured version satisfying the
+// PHP requirement of a composer.json, or "" if it has none
+func projectBestMatch(config *Config, manifestPath string) (string, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return "", err
+	}
+	var manifest composerManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return "", err
+	}
+	requirement := manifest.Require["php"]
+	if requirement == "" {
+		return "", nil
+	}
+	constraint, err := parseConstraint(requirement)
+	if err != nil {
+		return "", err
+	}
+	matches := matchingVersions(config, constraint)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%s is not satisfied by any configured version", requirement)
+	}
+	return matches[len(matches)-1], nil
+}
+
+// versionFileReaders describe the files --list-files reports in each
+// directory, in the order they are listed, with what each implies
+var versionFileReaders = []struct {
+	name string
+	read func(path string) string
+}{
+	{versionFile, readVersionFile},
+	{".mise.toml", readMiseVersion},
+	{"mise.toml", readMiseVersion},
+	{".rtx.toml", readMiseVersion},
+	{".tool-versions", describeToolVersions},
+	{ideaProjectFile, readIdeaVersion},
+	{ddevConfigFile, readDdevVersion},
+	{"composer.json", describeComposerManifest},
+	{platformCheckFile, describePlatformCheck},
+}
+
+// listVersionFiles prints every file that can influence the version, from
+// startDir up to the search boundary if bounded, with the version it implies
+func listVersionFiles(startDir string, bounded bool) int {
+	found := 0
+	walkUp(startDir, bounded, func(dir string) bool {
+		for _, reader := range versionFileReaders {
+			path := filepath.Join(dir, reader.name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			implies := reader.read(path)
+			if implies == "" {
+				implies = "(no PHP version)"
+			}
+			fmt.Printf("%s: %s\n", path, implies)
+			found++
+		}
+		return false
+	})
+	if found == 0 {
+		fmt.Printf("No version files found from %s upwards\n", startDir)
+	}
+	return 0
+}
+
+// readVersionFile returns the contents of a .php-version file
+func readVersionFile(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// describeToolVersions returns the php entry of an asdf .tool-versions file,
+// marked as listed only for reference since no source reads the file
+func describeToolVersions(path string) string {
+	version := readToolVersions(path)
+	if version == "" {
+		return ""
+	}
+	return version + " (not used for resolution)"
+}
+
+// describeComposerManifest summarises the PHP requirement and project type
+// of a composer.json
+func describeComposerManifest(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var manifest composerManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return "(unreadable: " + err.Error() + ")"
+	}
+	if manifest.Require["php"] == "" {
+		return ""
+	}
+	return "requires php " + manifest.Require["php"]
+}
+
+// describePlatformCheck returns the minimum version Composer's platform
+// check asserts
+func describePlatformCheck(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if required := parsePlatformCheck(string(content)); required != nil {
+		return "requires php >= " + formatVersionParts(required)
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completedCommands are the subcommands offered by shell completion;
+// completion itself is left out as it is only used to install the scripts
+var completedCommands = []string{
+	"check-composer", "config", "cross-check", "current", "doctor", "env", "export-env",
+	"ext-diff", "list", "matching", "pin-all", "test-all", "upgrade-check", "use", "which",
+}
+
+// versionCommands are the subcommands whose arguments are versions
+var versionCommands = []string{"ext-diff", "upgrade-check", "use"}
+
+// configCommands are the subcommands of "config"
+var configCommands = []string{"validate", "lint", "checksum"}
+
+// completionCommand prints a completion script for the given shell, or with
+// "versions" the names completed for a version argument. The scripts call
+// back into php-runner for the versions, so they follow config changes.
+func completionCommand(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner completion <bash|zsh|fish|versions>")
+		return 2
+	}
+
+	words := strings.Join(completedCommands, " ")
+	versionWords := strings.Join(versionCommands, " ")
+	configWords := strings.Join(configCommands, " ")
+	switch args[0] {
+	case "bash":
+		fmt.Printf(bashCompletion, words, strings.Join(versionCommands, "|"), configWords)
+	case "zsh":
+		fmt.Printf(zshCompletion, words, strings.Join(versionCommands, "|"), configWords)
+	case "fish":
+		fmt.Printf(fishCompletion, words, versionWords, configWords)
+	case "versions":
+		config, err := loadRunnerConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			return 1
+		}
+		for _, name := range completedVersions(config) {
+			fmt.Println(name)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported shell: %s (expected bash, zsh or fish)\n", args[0])
+		return 2
+	}
+	return 0
+}
+
+// completedVersions returns the configured versions followed by the aliases
+// that can stand for them
+func completedVersions(config *Config) []string {
+	names := configuredVersions(config)
+	var aliases []string
+	for alias := range config.Aliases {
+		if config.Versions[alias] == "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	for _, alias := range []string{aliasLatest, aliasOldest} {
+		if config.Versions[alias] == "" && config.Aliases[alias] == "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return append(names, aliases...)
+}
+
+// bashCompletion is filled with the commands, the version commands joined by
+// "|" and the config subcommands. Anything else completes file names, as
+// for php itself.
+const bashCompletion = `# bash completion for php-runner
+_php_runner() {
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        return
+    fi
+    case ${COMP_WORDS[1]} in
+    %s)
+        COMPREPLY=($(compgen -W "$(php-runner completion versions 2>/dev/null)" -- "$cur"))
+        ;;
+    config)
+        [ "$COMP_CWORD" -eq 2 ] && COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        ;;
+    esac
+}
+complete -o default -F _php_runner php-runner
+`
+
+// zshCompletion takes the same values as bashCompletion
+const zshCompletion = `#compdef php-runner
+_php_runner() {
+    local -a commands versions
+    commands=(%s)
+    if (( CURRENT == 2 )); then
+        _alternative 'commands:command:compadd -a commands' 'files:file:_files'
+        return
+    fi
+    case $words[2] in
+    %s)
+        versions=(${(f)"$(php-runner completion versions 2>/dev/null)"})
+        compadd -a versions
+        ;;
+    config)
+        (( CURRENT == 3 )) && compadd %s
+        ;;
+    *)
+        _files
+        ;;
+    esac
+}
+if [ "$funcstack[1]" = "_php_runner" ]; then
+    _php_runner "$@"
+else
+    compdef _php_runner php-runner
+fi
+`
+
+// fishCompletion is filled with the commands, the version commands and the
+// config subcommands, each separated by spaces
+const fishCompletion = `# fish completion for php-runner
+complete -c php-runner -n __fish_use_subcommand -a '%s'
+complete -c php-runner -n '__fish_seen_subcommand_from %s' -f -a '(php-runner completion versions 2>/dev/null)'
+complete -c php-runner -n '__fish_seen_subcommand_from config' -f -a '%s'
+`
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const platformCheckFile = "vendor/composer/platform_check.php"
+
+// composerManifest is the part of composer.json php-runner reads
+type composerManifest struct {
+	Type    string            `json:"type"`
+	Require map[string]string `json:"require"`
+}
+
+// findComposerManifest looks for a composer.json in the current and parent
+// directories, up to the search boundary if bounded, and returns its path
+// and contents. A file that can't be parsed ends the search, as Composer
+// would refuse to use it too.
+func findComposerManifest(startDir string, bounded bool) (string, *composerManifest) {
+	var manifestPath string
+	var manifest *composerManifest
+	walkUp(startDir, bounded, func(dir string) bool {
+		path := filepath.Join(dir, "composer.json")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		var parsed composerManifest
+		if err := json.Unmarshal(content, &parsed); err != nil {
+			warnf("ignoring %s: %v", path, err)
+			return true
+		}
+		// Composer treats a package without a type as a library
+		if parsed.Type == "" {
+			parsed.Type = "library"
+		}
+		manifestPath, manifest = path, &parsed
+		return true
+	})
+	return manifestPath, manifest
+}
+
+// composerSource selects the highest configured version satisfying the PHP
+// requirement in the nearest composer.json, for projects that declare it
+// there instead of in a .php-version. A requirement that can't be parsed or
+// satisfied is only a hint, so it is reported and the later sources decide,
+// unless --enforce-consistency makes it binding.
+func composerSource(cwd, searchDir string, config *Config) (string, error) {
+	manifestPath, manifest := findComposerManifest(searchDir, searchBounded(config))
+	if manifest == nil || manifest.Require["php"] == "" {
+		return "", nil
+	}
+	version, err := resolveVersionConstraint(config, manifest.Require["php"], manifestPath)
+	if err != nil && !opts.consistency {
+		warnf("%v", err)
+		return "", nil
+	}
+	return version, err
+}
+
+// composerLock is the part of composer.lock php-runner reads
+type composerLock struct {
+	PlatformOverrides map[string]string `json:"platform-overrides"`
+}
+
+// findLockedPlatform looks for a composer.lock in the current and parent
+// directories, up to the search boundary if bounded, and returns its path
+// and the PHP version it was locked for, from config.platform.php in
+// composer.json, if any
+func findLockedPlatform(startDir string, bounded bool) (string, string) {
+	var lockPath, version string
+	walkUp(startDir, bounded, func(dir string) bool {
+		path := filepath.Join(dir, "composer.lock")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		var lock composerLock
+		if err := json.Unmarshal(content, &lock); err != nil {
+			warnf("ignoring %s: %v", path, err)
+			return true
+		}
+		lockPath, version = path, lock.PlatformOverrides["php"]
+		return true
+	})
+	return lockPath, version
+}
+
+// resolveLockExact selects the configured version named exactly by the
+// platform override in composer.lock, for --lock-exact. ok is false if there
+// is no lock or it has no PHP override, so resolution carries on as usual.
+func resolveLockExact(searchDir string, config *Config) (resolution Resolution, ok bool, err error) {
+	lockPath, version := findLockedPlatform(searchDir, searchBounded(config))
+	if version == "" {
+		return Resolution{}, false, nil
+	}
+	if config.Versions[version] == "" {
+		return Resolution{}, true, fmt.Errorf("%s is locked to PHP %s, which is not configured exactly (nearest: %s)",
+			lockPath, version, strings.Join(nearVersions(config, version), ", "))
+	}
+	return Resolution{Version: version, Path: config.Versions[version], Source: sourceLock}, true, nil
+}
+
+// nearVersions returns the configured versions sharing the most leading
+// components with version, e.g. the other 8.1 releases for 8.1.27, or all
+// of them if none share even the major version
+func nearVersions(config *Config, version string) []string {
+	target, _ := parseVersionParts(versionKeyRe.FindString(version))
+	versions := configuredVersions(config)
+	for shared := len(target); shared > 0; shared-- {
+		var near []string
+		for _, candidate := range versions {
+			parts, ok := parseVersionParts(versionKeyRe.FindString(candidate))
+			if ok && len(parts) >= shared && compareVersionParts(parts[:shared], target[:shared]) == 0 {
+				near = append(near, candidate)
+			}
+		}
+		if len(near) > 0 {
+			return near
+		}
+	}
+	return versions
+}
+
+// workspaceRoot returns the highest directory above startDir, including
+// itself, that has a composer.json, or startDir if none has. If bounded it
+// looks no higher than the search boundary, so a composer.json in an
+// enclosing checkout or the home directory doesn't widen the workspace.
+func workspaceRoot(startDir string, bounded bool) string {
+	root := startDir
+	walkUp(startDir, bounded, func(dir string) bool {
+		if _, err := os.Stat(filepath.Join(dir, "composer.json")); err == nil {
+			root = dir
+		}
+		return false
+	})
+	return root
+}
+
+// resolveWorkspaceMin selects the lowest configured version satisfying the
+// PHP requirement of every composer.json in the workspace, for
+// --workspace-min. ok is false if none of them has a requirement.
+func resolveWorkspaceMin(searchDir string, config *Config) (resolution Resolution, ok bool, err error) {
+	root := workspaceRoot(searchDir, searchBounded(config))
+	candidates := configuredVersions(config)
+	var requirements []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && skippedComposerDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() != "composer.json" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var manifest composerManifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		requirement := manifest.Require["php"]
+		if requirement == "" {
+			return nil
+		}
+		constraint, err := parseConstraint(requirement)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		requirements = append(requirements, fmt.Sprintf("%s (%s)", requirement, path))
+
+		// Keep only the versions every manifest so far allows
+		var allowed []string
+		for _, version := range candidates {
+			if constraint.Allows(version) {
+				allowed = append(allowed, version)
+			}
+		}
+		candidates = allowed
+		return nil
+	})
+	if err != nil {
+		return Resolution{}, true, err
+	}
+	if len(requirements) == 0 {
+		return Resolution{}, false, nil
+	}
+	if len(candidates) == 0 {
+		return Resolution{}, true, fmt.Errorf("no configured version (%s) satisfies every PHP requirement in %s:\n  %s",
+			strings.Join(configuredVersions(config), ", "), root, strings.Join(requirements, "\n  "))
+	}
+	verbosef("%d manifests under %s allow %s", len(requirements), root, strings.Join(candidates, ", "))
+	return Resolution{Version: candidates[0], Path: config.Versions[candidates[0]], Source: sourceWorkspace}, true, nil
+}
+
+// checkComposerConsistency returns an error if the project's composer.json
+// requires a PHP version that the pinned version doesn't satisfy. Projects
+// without a PHP requirement pass.
+func checkComposerConsistency(searchDir, version string, bounded bool) error {
+	manifestPath, manifest := findComposerManifest(searchDir, bounded)
+	if manifest == nil || manifest.Require["php"] == "" {
+		return nil
+	}
+	requirement := manifest.Require["php"]
+	constraint, err := parseConstraint(requirement)
+	if err != nil {
+		return fmt.Errorf("%s: %v", manifestPath, err)
+	}
+	if !constraint.Allows(version) {
+		return fmt.Errorf("%s pins PHP %s, but %s requires php %s", versionFile, version, manifestPath, requirement)
+	}
+	return nil
+}
+
+// platformCheckRe matches the PHP_VERSION_ID comparison Composer writes into
+// platform_check.php, e.g. "if (!(PHP_VERSION_ID >= 80100)) {"
+var platformCheckRe = regexp.MustCompile(`PHP_VERSION_ID\s*>=\s*(\d+)`)
+
+// findPlatformRequirement looks for Composer's platform_check.php in the
+// current and parent directories, up to the search boundary if bounded, and
+// returns the minimum PHP version it asserts as major, minor and patch
+// components
+func findPlatformRequirement(startDir string, bounded bool) (string, []int) {
+	var checkPath string
+	var required []int
+	walkUp(startDir, bounded, func(dir string) bool {
+		path := filepath.Join(dir, platformCheckFile)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		if required = parsePlatformCheck(string(content)); required != nil {
+			checkPath = path
+		}
+		return true
+	})
+	return checkPath, required
+}
+
+// parsePlatformCheck extracts the minimum PHP_VERSION_ID from the contents of
+// platform_check.php, returning nil if there is none
+func parsePlatformCheck(content string) []int {
+	matches := platformCheckRe.FindStringSubmatch(content)
+	if len(matches) < 2 {
+		return nil
+	}
+	id, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil
+	}
+	// PHP_VERSION_ID is major*10000 + minor*100 + patch
+	return []int{id / 10000, id / 100 % 100, id % 100}
+}
+
+// checkPlatformRequirement warns if the selected version is older than the
+// minimum asserted by the project's Composer platform check. Only as many
+// components as the version key has are compared, so "8.2" satisfies 8.2.5,
+// and a suffix such as "-zts" is ignored.
+func checkPlatformRequirement(cwd, version string, bounded bool) {
+	checkPath, required := findPlatformRequirement(cwd, bounded)
+	if required == nil {
+		return
+	}
+	selected, ok := parseVersionParts(versionKeyRe.FindString(version))
+	if !ok {
+		return
+	}
+	minimum := required
+	if len(selected) < len(minimum) {
+		minimum = minimum[:len(selected)]
+	}
+	if compareVersionParts(selected, minimum) < 0 {
+		warnf("%s requires PHP >= %s but version %s was selected",
+			checkPath, formatVersionParts(required), version)
+	}
+}
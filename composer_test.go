@@ -0,0 +1,244 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestComposerSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		require     string
+		consistency bool
+		want        string
+		wantErr     bool
+	}{
+		{name: "satisfiable", require: "^8.1", want: "8.3"},
+		{name: "unsatisfiable", require: "^7.4"},
+		{name: "unparsable", require: "not a constraint"},
+		{name: "unsatisfiable with --enforce-consistency", require: "^7.4", consistency: true, wantErr: true},
+		{name: "satisfiable with --enforce-consistency", require: ">=8.1 <8.3", consistency: true, want: "8.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			opts.consistency = tt.consistency
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "composer.json"), `{"require": {"php": "`+tt.require+`"}}`)
+			config := newConfig()
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config.Versions[version] = "/usr/bin/php" + version
+			}
+
+			got, err := composerSource(dir, dir, config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("version = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlatformCheck(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []int
+	}{
+		{"if (!(PHP_VERSION_ID >= 80100)) {", []int{8, 1, 0}},
+		{"if (!(PHP_VERSION_ID >= 80205)) {", []int{8, 2, 5}},
+		{"if (!(PHP_VERSION_ID>=70400)) {", []int{7, 4, 0}},
+		{"<?php // no version check", nil},
+	}
+	for _, tt := range tests {
+		if got := parsePlatformCheck(tt.content); !slices.Equal(got, tt.want) {
+			t.Errorf("parsePlatformCheck(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestPlatformCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string // PHP_VERSION_ID asserted by platform_check.php
+		version  string // version pinned in .php-version
+		wantWarn bool
+	}{
+		{name: "satisfied", id: "80100", version: "8.2"},
+		{name: "satisfied exactly", id: "80200", version: "8.2"},
+		{name: "patch not compared", id: "80205", version: "8.2"},
+		{name: "unsatisfied", id: "80100", version: "8.0", wantWarn: true},
+		{name: "unsatisfied major", id: "80000", version: "7.4", wantWarn: true},
+		{name: "suffixed version", id: "80100", version: "8.0-zts", wantWarn: true},
+		{name: "suffixed version satisfied", id: "80100", version: "8.2-zts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"7.4", "8.0", "8.0-zts", "8.2", "8.2-zts"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			writeFile(t, filepath.Join(root, platformCheckFile), "<?php\nif (!(PHP_VERSION_ID >= "+tt.id+")) {\n    exit(1);\n}\n")
+
+			writeFile(t, filepath.Join(root, versionFile), tt.version+"\n")
+
+			_, stderr, code := runRunner(t, root, nil, "--platform-check", "x.php")
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			warned := strings.Contains(stderr, "requires PHP >= ") && strings.Contains(stderr, "but version "+tt.version+" was selected")
+			if warned != tt.wantWarn {
+				t.Errorf("stderr = %q, want a warning: %v", stderr, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestCheckComposer(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifests map[string]string // composer.json contents by directory
+		want      []string          // lines printed, with {{root}} for the checked directory
+		wantCode  int
+	}{
+		{
+			name: "all satisfiable",
+			manifests: map[string]string{
+				"app": `{"require": {"php": "^8.1"}}`,
+				"lib": `{"require": {"php": ">=8.3"}}`,
+				"cli": `{"require": {"symfony/console": "^7.0"}}`,
+			},
+			want: []string{
+				"ok   {{root}}/app/composer.json: ^8.1 (8.1, 8.2, 8.3)",
+				"ok   {{root}}/lib/composer.json: >=8.3 (8.3)",
+				"0 of 2 manifests with a PHP requirement can't be satisfied (configured: 8.1, 8.2, 8.3)",
+			},
+		},
+		{
+			name: "unsatisfiable",
+			manifests: map[string]string{
+				"app":    `{"require": {"php": "^8.1"}}`,
+				"legacy": `{"require": {"php": "^7.4"}}`,
+			},
+			want: []string{
+				"FAIL {{root}}/legacy/composer.json: ^7.4 is not satisfied by any configured version",
+				"1 of 2 manifests with a PHP requirement can't be satisfied (configured: 8.1, 8.2, 8.3)",
+			},
+			wantCode: 1,
+		},
+		{
+			name: "dependencies skipped",
+			manifests: map[string]string{
+				"app":              `{"require": {"php": "^8.1"}}`,
+				"app/vendor/old":   `{"require": {"php": "^5.6"}}`,
+				"app/node_modules": `{"require": {"php": "^5.6"}}`,
+			},
+			want: []string{"0 of 1 manifests with a PHP requirement can't be satisfied (configured: 8.1, 8.2, 8.3)"},
+		},
+		{
+			name: "malformed",
+			manifests: map[string]string{
+				"app":    `{"require": {"php": "^8.1"}}`,
+				"broken": `{"require": `,
+			},
+			want: []string{
+				"FAIL {{root}}/broken/composer.json: unexpected end of JSON input",
+				"0 of 1 manifests with a PHP requirement can't be satisfied (configured: 8.1, 8.2, 8.3)",
+				"1 manifests couldn't be parsed",
+			},
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			repo := filepath.Join(root, "repo")
+			for dir, content := range tt.manifests {
+				writeFile(t, filepath.Join(repo, filepath.FromSlash(dir), "composer.json"), content)
+			}
+
+			stdout, stderr, code := runRunner(t, root, nil, "check-composer", repo)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			for _, line := range tt.want {
+				if line = strings.ReplaceAll(line, "{{root}}", repo); !strings.Contains(stdout, line+"\n") {
+					t.Errorf("stdout = %q, want %q", stdout, line)
+				}
+			}
+			if strings.Contains(stdout, "^5.6") {
+				t.Errorf("stdout = %q, want dependencies skipped", stdout)
+			}
+		})
+	}
+}
+
+func TestEnforceConsistency(t *testing.T) {
+	tests := []struct {
+		name    string
+		pin     string // .php-version in the project, if any
+		require string // composer.json's require.php, if any
+		enforce bool
+		want    string
+		wantErr string
+	}{
+		{name: "consistent", pin: "8.2", require: "^8.1", enforce: true, want: "8.2"},
+		{name: "consistent constraint pin", pin: "^8.1", require: ">=8.2", enforce: true, want: "8.3"},
+		{name: "inconsistent", pin: "8.1", require: "^8.2", enforce: true, wantErr: "pins PHP 8.1, but {{project}}/composer.json requires php ^8.2"},
+		{name: "inconsistent unenforced", pin: "8.1", require: "^8.2", want: "8.1"},
+		{name: "no requirement", pin: "8.1", enforce: true, want: "8.1"},
+		{name: "no pin", require: "~8.2.0", enforce: true, want: "8.2"},
+		{name: "bad requirement", pin: "8.1", require: "^^8", enforce: true, wantErr: "{{project}}/composer.json: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, "project")
+			manifest := "{}"
+			if tt.require != "" {
+				manifest = `{"require": {"php": "` + tt.require + `"}}`
+			}
+			writeFile(t, filepath.Join(project, "composer.json"), manifest)
+			if tt.pin != "" {
+				writeFile(t, filepath.Join(project, versionFile), tt.pin+"\n")
+			}
+
+			args := []string{"current"}
+			if tt.enforce {
+				args = append([]string{"--enforce-consistency"}, args...)
+			}
+			stdout, stderr, code := runRunner(t, project, nil, args...)
+			if tt.wantErr != "" {
+				if want := strings.ReplaceAll(tt.wantErr, "{{project}}", project); code == 0 || !strings.Contains(stderr, want) {
+					t.Errorf("current exited %d with %q, want an error containing %q", code, stderr, want)
+				}
+				return
+			}
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigWithoutVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty", content: "", wantErr: "no PHP versions defined"},
+		{name: "settings only", content: "# comment\nfile_mode: 0644\n", wantErr: "no PHP versions defined"},
+		{name: "all missing", content: "8.1: {{root}}/missing8.1\n8.2: {{root}}/missing8.2\n", wantErr: "none of the 2 configured PHP executables exist"},
+		{name: "one present", content: "8.1: {{root}}/missing8.1\n8.2: {{root}}/php8.2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), strings.ReplaceAll(tt.content, "{{root}}", root))
+
+			config, err := loadConfig([]string{configPath})
+			if tt.wantErr == "" {
+				if err != nil || config.Versions["8.2"] == "" {
+					t.Errorf("loadConfig = %v, %v, want 8.2 configured", config, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadConfig error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigCommandsEveryFile(t *testing.T) {
+	tests := []struct {
+		command  string
+		want     []string // output lines, with {user} and {home} for the two files
+		wantCode int
+	}{
+		{
+			command:  "validate",
+			want:     []string{"{user}: 8.2 is defined more than once (lines 1, 2); line 2 wins", "{home}: OK"},
+			wantCode: 1,
+		},
+		{
+			command: "lint",
+			want:    []string{"{user}:2: {php} is also configured as version 8.2", "{home}: no problems found"},
+		},
+		{
+			command: "checksum",
+			want:    []string{"{user}: checksum: sha256:", "{home}: checksum: sha256:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			isolate(t)
+			home := os.Getenv("HOME")
+			php := writeStub(t, filepath.Join(home, "bin", "php8.2"), "exit 0")
+			user := writeFile(t, filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "php-runner", configFileName), "8.2: "+php+"\n8.2: "+php+"\n")
+			homeConfig := writeFile(t, filepath.Join(home, "."+configFileName), "8.1: "+php+"\n")
+
+			stdout, stderr, code := runRunner(t, home, nil, "config", tt.command)
+			if code != tt.wantCode {
+				t.Errorf("exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			lines := strings.Split(strings.TrimSpace(stdout), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("output:\n%s\nwant %d lines", stdout, len(tt.want))
+			}
+			for i, want := range tt.want {
+				want = strings.NewReplacer("{user}", user, "{home}", homeConfig, "{php}", php).Replace(want)
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("line %d = %q, want %q", i+1, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// versionBound is a single comparison such as ">=8.1"
+type versionBound struct {
+	op    string // one of "=", "!=", "<", "<=", ">", ">="
+	parts []int
+}
+
+// versionConstraint is a Composer-style constraint: any one of its
+// alternatives must hold, and an alternative holds when all of its bounds do.
+// An alternative with no bounds ("*") matches every version.
+type versionConstraint [][]versionBound
+
+// parseConstraint parses the subset of Composer's constraint syntax that is
+// meaningful for PHP versions:
+//
+//	8.2              exact; "8" matches any 8.x and "8.2.3" the 8.2 line
+//	>=7.4 <8.3       comparisons, combined with spaces or commas
+//	^8.1             >=8.1 <9.0
+//	~8.1, ~8.1.2     >=8.1 <9.0 and >=8.1.2 <8.2
+//	8.*, 8.1.x       wildcards
+//	7.4 - 8.1        inclusive range
+//	^7.4 || ^8.0     alternatives
+func parseConstraint(text string) (versionConstraint, error) {
+	var constraint versionConstraint
+	for _, alternative := range strings.Split(strings.ReplaceAll(text, "||", "|"), "|") {
+		tokens := strings.Fields(strings.ReplaceAll(alternative, ",", " "))
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", text)
+		}
+
+		bounds := []versionBound{}
+		for i := 0; i < len(tokens); i++ {
+			// "a - b" is an inclusive range
+			if i+2 < len(tokens) && tokens[i+1] == "-" {
+				low, lowOk := parseConstraintVersion(tokens[i])
+				high, highOk := parseConstraintVersion(tokens[i+2])
+				if !lowOk || !highOk {
+					return nil, fmt.Errorf("invalid version constraint %q", text)
+				}
+				bounds = append(bounds, versionBound{">=", low}, versionBound{"<=", high})
+				i += 2
+				continue
+			}
+
+			tokenBounds, ok := parseConstraintToken(tokens[i])
+			if !ok {
+				return nil, fmt.Errorf("invalid version constraint %q", text)
+			}
+			bounds = append(bounds, tokenBounds...)
+		}
+		constraint = append(constraint, bounds)
+	}
+	return constraint, nil
+}
+
+// parseConstraintToken turns one constraint token into its bounds
+func parseConstraintToken(token string) ([]versionBound, bool) {
+	if token == "*" || token == "x" {
+		return nil, true
+	}
+
+	// Wildcards: "8.*" is >=8 <9
+	if base, ok := strings.CutSuffix(token, ".*"); ok {
+		return wildcardBounds(base)
+	}
+	if base, ok := strings.CutSuffix(token, ".x"); ok {
+		return wildcardBounds(base)
+	}
+
+	switch {
+	case strings.HasPrefix(token, "^"):
+		parts, ok := parseConstraintVersion(token[1:])
+		if !ok {
+			return nil, false
+		}
+		return []versionBound{{">=", parts}, {"<", []int{parts[0] + 1}}}, true
+	case strings.HasPrefix(token, "~"):
+		parts, ok := parseConstraintVersion(token[1:])
+		if !ok {
+			return nil, false
+		}
+		// The second to last component may grow: ~8.1 is <9, ~8.1.2 is <8.2
+		upper := []int{parts[0] + 1}
+		if len(parts) > 2 {
+			upper = append(append([]int{}, parts[:len(parts)-2]...), parts[len(parts)-2]+1)
+		}
+		return []versionBound{{">=", parts}, {"<", upper}}, true
+	}
+
+	for _, op := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(token, op); ok {
+			parts, ok := parseConstraintVersion(rest)
+			if op == "==" {
+				op = "="
+			}
+			return []versionBound{{op, parts}}, ok
+		}
+	}
+
+	parts, ok := parseConstraintVersion(token)
+	return []versionBound{{"=", parts}}, ok
+}
+
+// wildcardBounds returns the bounds for "<base>.*"
+func wildcardBounds(base string) ([]versionBound, bool) {
+	parts, ok := parseConstraintVersion(base)
+	if !ok {
+		return nil, false
+	}
+	upper := append(append([]int{}, parts[:len(parts)-1]...), parts[len(parts)-1]+1)
+	return []versionBound{{">=", parts}, {"<", upper}}, true
+}
+
+// parseConstraintVersion parses a version in a constraint, allowing a "v"
+// prefix as Composer does
+func parseConstraintVersion(version string) ([]int, bool) {
+	parts, ok := parseVersionParts(strings.TrimPrefix(version, "v"))
+	return parts, ok && len(parts) > 0
+}
+
+// Allows reports whether a configured version key satisfies the constraint.
+// Only as many components as the key has are compared, so the key "8.2"
+// stands for the whole 8.2 line and satisfies ">=8.2.5". Keys without a
+// numeric version never match.
+func (c versionConstraint) Allows(version string) bool {
+	key, ok := parseVersionParts(versionKeyRe.FindString(version))
+	if !ok {
+		return false
+	}
+	for _, bounds := range c {
+		if boundsAllow(bounds, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func boundsAllow(bounds []versionBound, key []int) bool {
+	for _, bound := range bounds {
+		parts := bound.parts
+		if len(parts) > len(key) {
+			parts = parts[:len(key)]
+		}
+		cmp := compareVersionParts(key, parts)
+		// An exact version only constrains the components it names
+		if bound.op == "=" || bound.op == "!=" {
+			prefix := key
+			if len(prefix) > len(parts) {
+				prefix = prefix[:len(parts)]
+			}
+			cmp = compareVersionParts(prefix, parts)
+		}
+
+		var ok bool
+		switch bound.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchingVersions returns the configured versions that satisfy the
+// constraint, lowest first
+func matchingVersions(config *Config, constraint versionConstraint) []string {
+	var matches []string
+	for _, version := range configuredVersions(config) {
+		if constraint.Allows(version) {
+			matches = append(matches, version)
+		}
+	}
+	return matches
+}
+
+// isVersionConstraint reports whether text, e.g. the content of a
+// .php-version file, is a constraint such as "^8.1" or "8.*" rather than a
+// plain version key
+func isVersionConstraint(text string) bool {
+	return strings.ContainsAny(text, "^~*<>=!|, ") || strings.HasSuffix(text, ".x")
+}
+
+// resolveVersionConstraint returns the highest configured version that
+// satisfies the constraint read from file
+func resolveVersionConstraint(config *Config, text, file string) (string, error) {
+	constraint, err := parseConstraint(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", file, err)
+	}
+	matches := matchingVersions(config, constraint)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%s requires PHP %s, but no configured version satisfies it (available: %s)",
+			file, text, strings.Join(configuredVersions(config), ", "))
+	}
+	return matches[len(matches)-1], nil
+}
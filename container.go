@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// containerRuntimes maps the config path schemes that select a container
+// image instead of a local binary to the runtime that runs them
+var containerRuntimes = map[string]string{
+	"docker://": "docker",
+	"podman://": "podman",
+}
+
+// parseContainerPath reports whether a configured path names a container
+// image, e.g. "docker://php:8.2-cli", returning the runtime and image
+func parseContainerPath(path string) (string, string, bool) {
+	for scheme, runtimeName := range containerRuntimes {
+		if image, ok := strings.CutPrefix(path, scheme); ok && image != "" {
+			return runtimeName, image, true
+		}
+	}
+	return "", "", false
+}
+
+// containerCommand builds the command that runs php inside image with the
+// working directory mounted, so relative paths in args keep working
+func containerCommand(runtimeName, image, cwd string, args []string) (string, []string, error) {
+	runtimePath, err := exec.LookPath(runtimeName)
+	if err != nil {
+		return "", nil, fmt.Errorf("container runtime %s not found: %v", runtimeName, err)
+	}
+	// Input from --stdin-file is never a terminal
+	tty := opts.stdinFile == "" && isTerminal(os.Stdin)
+	return runtimePath, containerArgs(image, cwd, args, tty), nil
+}
+
+// containerArgs returns the runtime arguments for running php in image
+func containerArgs(image, cwd string, args []string, tty bool) []string {
+	// Mount the working directory at the same path so absolute paths inside
+	// the project resolve too; Windows paths can't be used inside the container.
+	mountPoint := cwd
+	if runtime.GOOS == "windows" {
+		mountPoint = "/app"
+	}
+
+	runArgs := []string{"run", "--rm", "-i"}
+	if tty {
+		runArgs = append(runArgs, "-t")
+	}
+	// Run as the calling user so files written to the mount aren't owned by root
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 && gid >= 0 {
+		runArgs = append(runArgs, "--user", fmt.Sprintf("%d:%d", uid, gid))
+	}
+	runArgs = append(runArgs, "-v", cwd+":"+mountPoint, "-w", mountPoint, image, "php")
+	return append(runArgs, args...)
+}
+
+// isTerminal reports whether file is attached to a terminal. The null device
+// is a character device too, so it is ruled out explicitly.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
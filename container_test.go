@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseContainerPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantRuntime string
+		wantImage   string
+		wantOK      bool
+	}{
+		{"docker://php:8.2-cli", "docker", "php:8.2-cli", true},
+		{"podman://docker.io/library/php:8.3", "podman", "docker.io/library/php:8.3", true},
+		{"docker://", "", "", false},
+		{"/usr/bin/php8.2", "", "", false},
+	}
+	for _, tt := range tests {
+		runtimeName, image, ok := parseContainerPath(tt.path)
+		if runtimeName != tt.wantRuntime || image != tt.wantImage || ok != tt.wantOK {
+			t.Errorf("parseContainerPath(%q) = %q, %q, %v, want %q, %q, %v", tt.path, runtimeName, image, ok, tt.wantRuntime, tt.wantImage, tt.wantOK)
+		}
+	}
+}
+
+func TestContainerArgs(t *testing.T) {
+	cwd := "/home/me/project"
+	mount := cwd
+	if runtime.GOOS == "windows" {
+		mount = "/app"
+	}
+	var user []string
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 && gid >= 0 {
+		user = []string{"--user", fmt.Sprintf("%d:%d", uid, gid)}
+	}
+
+	tests := []struct {
+		name  string
+		image string
+		args  []string
+		tty   bool
+		want  []string
+	}{
+		{
+			name:  "script",
+			image: "php:8.2-cli",
+			args:  []string{"artisan", "migrate"},
+			want:  slices.Concat([]string{"run", "--rm", "-i"}, user, []string{"-v", cwd + ":" + mount, "-w", mount, "php:8.2-cli", "php", "artisan", "migrate"}),
+		},
+		{
+			name:  "terminal",
+			image: "php:8.3-cli",
+			tty:   true,
+			want:  slices.Concat([]string{"run", "--rm", "-i", "-t"}, user, []string{"-v", cwd + ":" + mount, "-w", mount, "php:8.3-cli", "php"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containerArgs(tt.image, cwd, tt.args, tt.tty); !slices.Equal(got, tt.want) {
+				t.Errorf("args = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerCommand(t *testing.T) {
+	tests := []struct {
+		runtime string
+		onPath  bool
+		wantErr string
+	}{
+		{runtime: "docker", onPath: true},
+		{runtime: "podman", onPath: true},
+		{runtime: "podman", wantErr: "container runtime podman not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.runtime, func(t *testing.T) {
+			isolate(t)
+			bin := t.TempDir()
+			var runtimePath string
+			if tt.onPath {
+				runtimePath = writeStub(t, filepath.Join(bin, tt.runtime), "exit 0")
+			}
+			t.Setenv("PATH", bin)
+
+			path, args, err := containerCommand(tt.runtime, "php:8.2-cli", "/project", []string{"-v"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != runtimePath {
+				t.Errorf("path = %s, want %s", path, runtimePath)
+			}
+			if !slices.Contains(args, "/project:/project") || !slices.Equal(args[len(args)-3:], []string{"php:8.2-cli", "php", "-v"}) {
+				t.Errorf("args = %q", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// runRunnerInDeletedDir runs php-runner like runRunner, but from a directory
+// removed once the process is in it, and returns its combined output
+func runRunnerInDeletedDir(t *testing.T, env []string, args ...string) (string, int) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("only Linux lets a process sit in a removed directory")
+	}
+	gone := filepath.Join(t.TempDir(), "gone")
+	if err := os.Mkdir(gone, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	script := `cd "$1" && rmdir "$1" && shift && exec "$@"`
+	cmd := exec.Command("/bin/sh", append([]string{"-c", script, "sh", gone, os.Args[0]}, args...)...)
+	cmd.Env = append(append(os.Environ(), runnerMainEnv+"=1"), env...)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return output.String(), cmd.ProcessState.ExitCode()
+}
+
+func TestDeletedWorkingDir(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantCode int
+		want     string // in the combined output
+	}{
+		{args: []string{"list"}, want: "8.2"},
+		{args: []string{"--show-deprecations"}, want: "No configured versions are deprecated"},
+		{args: []string{"current"}, wantCode: 1, want: "has it been deleted?"},
+		{args: []string{"x.php"}, wantCode: exitUnavailable, want: "has it been deleted?"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "echo ran")
+			configPath := writeFile(t, filepath.Join(root, configFileName), "8.2: "+php+"\n")
+
+			output, code := runRunnerInDeletedDir(t, []string{"PHP_RUNNER_CONFIG=" + configPath}, tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, output)
+			}
+			if !strings.Contains(output, tt.want) {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ddevConfigFile is where DDEV keeps a project's settings
+var ddevConfigFile = filepath.Join(".ddev", "config.yaml")
+
+// ddevConfig is the part of .ddev/config.yaml php-runner reads, e.g.
+//
+//	php_version: "8.2"
+type ddevConfig struct {
+	PHPVersion string `yaml:"php_version"`
+}
+
+// findDdevVersion looks for a DDEV .ddev/config.yaml in the current and
+// parent directories, up to the search boundary if bounded, and returns the
+// PHP version it sets
+func findDdevVersion(startDir string, bounded bool) (string, string) {
+	var version, ddevPath string
+	walkUp(startDir, bounded, func(dir string) bool {
+		ddevPath = filepath.Join(dir, ddevConfigFile)
+		version = readDdevVersion(ddevPath)
+		return version != ""
+	})
+	if version == "" {
+		return "", ""
+	}
+	return version, ddevPath
+}
+
+// readDdevVersion returns the php_version from a DDEV config.yaml, or "" if
+// the file is missing, malformed or doesn't set one
+func readDdevVersion(ddevPath string) string {
+	data, err := os.ReadFile(ddevPath)
+	if err != nil {
+		return ""
+	}
+
+	var config ddevConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return ""
+	}
+	return config.PHPVersion
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sampleDdevConfig is a .ddev/config.yaml as "ddev config" writes it
+const sampleDdevConfig = `name: shop
+type: laravel
+docroot: public
+php_version: "8.2"
+webserver_type: nginx-fpm
+xdebug_enabled: false
+additional_hostnames: []
+additional_fqdns: []
+database:
+  type: mariadb
+  version: "10.11"
+use_dns_when_possible: true
+composer_version: "2"
+web_environment: []
+nodejs_version: "20"
+`
+
+func TestReadDdevVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "sample", content: sampleDdevConfig, want: "8.2"},
+		{name: "unquoted", content: "name: shop\nphp_version: 8.3\n", want: "8.3"},
+		{name: "no php_version", content: "name: shop\ntype: php\n"},
+		{name: "nested php_version", content: "name: shop\nweb_extra:\n  php_version: \"8.1\"\n"},
+		{name: "malformed", content: "name: [shop\nphp_version: \"8.2\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), ddevConfigFile), tt.content)
+			if got := readDdevVersion(path); got != tt.want {
+				t.Errorf("readDdevVersion = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDdevSource(t *testing.T) {
+	tests := []struct {
+		name string
+		ddev string // where the sample config is, relative to the project
+		args []string
+		pin  bool // whether the project also has a .php-version pinning 8.1
+		want string
+	}{
+		{name: "project root", ddev: ".", args: []string{"--ddev"}, want: "8.2"},
+		{name: "walking up", ddev: "..", args: []string{"--ddev"}, want: "8.2"},
+		{name: "not opted in", ddev: ".", want: "8.3"},
+		{name: "in sources", ddev: ".", args: []string{"--sources", "ddev,default"}, want: "8.2"},
+		{name: "pin outranks", ddev: ".", args: []string{"--ddev"}, pin: true, want: "8.1"},
+		{name: "no config", args: []string{"--ddev"}, want: "8.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config+"default: 8.3\n")
+			// The repository holds the project, so a DDEV config above it is
+			// still within the search
+			if err := os.MkdirAll(filepath.Join(root, "repo", ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			project := filepath.Join(root, "repo", "app")
+			if err := os.MkdirAll(project, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.ddev != "" {
+				writeFile(t, filepath.Join(project, tt.ddev, ddevConfigFile), sampleDdevConfig)
+			}
+			if tt.pin {
+				writeFile(t, filepath.Join(project, versionFile), "8.1\n")
+			}
+
+			// PATH has no php, so only the DDEV config and the default decide
+			env := []string{"PATH=" + t.TempDir(), "PHP_RUNNER_CONFIG=" + configPath}
+			stdout, stderr, code := runRunner(t, project, env, append(append([]string{}, tt.args...), "current")...)
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstalledDefault(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string // versions whose executable exists
+		saved     string   // existing .php-runner-default, if any
+		want      string
+		wantSaved string // .php-runner-default after running PHP
+	}{
+		{name: "highest installed", installed: []string{"7.4", "8.1"}, want: "8.1", wantSaved: "8.1"},
+		{name: "all installed", installed: []string{"7.4", "8.1", "8.3"}, want: "8.3", wantSaved: "8.3"},
+		{name: "saved choice kept", installed: []string{"7.4", "8.1", "8.3"}, saved: "7.4", want: "7.4", wantSaved: "7.4"},
+		{name: "saved choice no longer configured", installed: []string{"8.1"}, saved: "5.6", want: "8.1", wantSaved: "8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "sources: [default]\n"
+			for _, version := range []string{"7.4", "8.1", "8.3"} {
+				path := filepath.Join(root, "php"+version)
+				for _, installed := range tt.installed {
+					if installed == version {
+						writeStub(t, path, "echo ran "+version)
+					}
+				}
+				config += version + ": " + path + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			statePath := filepath.Join(root, "conf", defaultStateName)
+			if tt.saved != "" {
+				writeFile(t, statePath, tt.saved+"\n")
+			}
+			env := []string{"PHP_RUNNER_CONFIG=" + configPath}
+
+			stdout, stderr, code := runRunner(t, root, env, "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+			if saved := readState(t, statePath); saved != tt.saved {
+				t.Errorf("after current, saved default = %q, want it untouched (%q)", saved, tt.saved)
+			}
+
+			stdout, stderr, code = runRunner(t, root, env, "x.php")
+			if code != 0 {
+				t.Fatalf("running PHP exited %d: %s", code, stderr)
+			}
+			if want := "ran " + tt.want; strings.TrimSpace(stdout) != want {
+				t.Errorf("running PHP printed %q, want %q", stdout, want)
+			}
+			if saved := readState(t, statePath); saved != tt.wantSaved {
+				t.Errorf("after running PHP, saved default = %q, want %q", saved, tt.wantSaved)
+			}
+			if announced := strings.Contains(stderr, "as the default"); announced != (tt.saved != tt.wantSaved) {
+				t.Errorf("stderr = %q, want the choice announced only when it is newly saved", stderr)
+			}
+		})
+	}
+}
+
+// readState returns the trimmed contents of a state file, or "" if it doesn't exist
+func readState(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ""
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShowDeprecations(t *testing.T) {
+	tests := []struct {
+		name       string
+		deprecated string // deprecated settings
+		want       string
+	}{
+		{name: "none", want: "No configured versions are deprecated\n"},
+		{
+			name:       "only deprecated listed",
+			deprecated: "deprecated.8.1: move to 8.3\ndeprecated.7.4: end of life, move to 8.2\n",
+			want:       "7.4: end of life, move to 8.2\n8.1: move to 8.3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := tt.deprecated
+			for _, version := range []string{"7.4", "8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+
+			stdout, stderr, code := runRunner(t, root, nil, "--show-deprecations")
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// doctorCommand reports on everything resolution depends on: the config
+// files searched, each configured version's executable, the cwd's
+// .php-version and the php on PATH. Unlike a normal run it carries on past
+// problems, and exits non-zero if any was found so it can serve as a health
+// check.
+func doctorCommand(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner doctor")
+		return 2
+	}
+	problems := 0
+
+	fmt.Println("Config files:")
+	configPaths := configSearchPaths()
+	if path := explicitConfigFile(); path != "" {
+		configPaths = []string{path}
+	}
+	// Every file found is loaded, the later ones overridden by earlier ones
+	configUsed := false
+	for _, path := range configPaths {
+		status := "not found"
+		if _, err := os.Stat(path); err == nil {
+			status, configUsed = "found, used", true
+		}
+		fmt.Printf("  %-40s %s\n", path, status)
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		problems++
+		fmt.Printf("  error: %v\n", err)
+	} else {
+		if !configUsed {
+			fmt.Println("  using the built-in config")
+		}
+
+		fmt.Println("\nVersions:")
+		versions := configuredVersions(config)
+		for version := range config.Missing {
+			if _, ok := config.Versions[version]; !ok {
+				versions = append(versions, version)
+			}
+		}
+		sortVersions(versions)
+		for _, version := range versions {
+			path, ok := config.Versions[version]
+			if !ok {
+				path = config.Missing[version]
+			}
+			status, healthy := executableStatus(path)
+			if !healthy {
+				problems++
+			}
+			fmt.Printf("  %-8s %-40s %s\n", version, path, status)
+		}
+		if len(versions) == 0 {
+			problems++
+			fmt.Println("  none configured")
+		}
+	}
+
+	fmt.Println("\nProject:")
+	if cwd, err := workingDir(); err != nil {
+		problems++
+		fmt.Printf("  error: %v\n", err)
+	} else if config != nil {
+		version, versionPath := findPhpVersionFile(projectSearchDir(cwd), searchBounded(config))
+		if versionPath != "" {
+			fmt.Printf("  %s: %s\n", versionPath, version)
+		} else {
+			fmt.Printf("  no %s found from %s\n", versionFile, cwd)
+		}
+		if resolution, err := resolveVersion(cwd, config); err != nil {
+			problems++
+			fmt.Printf("  error: %v\n", err)
+		} else {
+			fmt.Printf("  would run PHP %s (from %s)\n", resolution.Version, resolution.Source)
+		}
+	}
+
+	fmt.Println("\nPATH:")
+	if path, err := exec.LookPath("php"); err != nil {
+		fmt.Println("  no php on PATH")
+	} else if version, err := getCurrentPhpVersion(); err != nil {
+		fmt.Printf("  %s: %v\n", path, err)
+	} else {
+		fmt.Printf("  %s: PHP %s\n", path, version)
+	}
+
+	if problems > 0 {
+		fmt.Printf("\n%d problems found\n", problems)
+		return 1
+	}
+	fmt.Println("\nNo problems found")
+	return 0
+}
+
+// executableStatus describes whether a configured PHP path can be run, and
+// reports false if it can't
+func executableStatus(path string) (string, bool) {
+	if _, _, isContainer := parseContainerPath(path); isContainer {
+		return "container image, not checked", true
+	}
+	if err := checkExecutable(path); os.IsNotExist(err) {
+		if _, linkErr := os.Lstat(path); linkErr == nil {
+			return "broken symlink", false
+		}
+		return "not found", false
+	} else if err != nil {
+		return err.Error(), false
+	}
+	if target, err := filepath.EvalSymlinks(path); err == nil && target != path {
+		return "ok (" + target + ")", true
+	}
+	return "ok", true
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecutableStatus(t *testing.T) {
+	// Resolved, so only the symlinks made here show up as symlinks
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	php := writeStub(t, filepath.Join(dir, "php"), "exit 0")
+	plain := writeFile(t, filepath.Join(dir, "plain"), "")
+	link := filepath.Join(dir, "link")
+	dangling := filepath.Join(dir, "dangling")
+	for target, name := range map[string]string{php: link, filepath.Join(dir, "gone"): dangling} {
+		if err := os.Symlink(target, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		healthy bool
+	}{
+		{php, "ok", true},
+		{link, "ok (" + php + ")", true},
+		{"docker://php:8.3-cli", "container image, not checked", true},
+		{filepath.Join(dir, "missing"), "not found", false},
+		{dangling, "broken symlink", false},
+		{dir, "not a regular file", false},
+		{plain, "not executable", false},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			got, healthy := executableStatus(tt.path)
+			if got != tt.want || healthy != tt.healthy {
+				t.Errorf("executableStatus = %q, %v, want %q, %v", got, healthy, tt.want, tt.healthy)
+			}
+		})
+	}
+}
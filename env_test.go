@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnvCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      map[string]string // variables set for the run
+		unset    []string          // variables removed for the run
+		want     []string          // prefixes of lines the output must contain
+		order    []string          // lines that must appear in this order
+		path     string            // "php" to put a php on PATH, "-" for a PATH without one
+		unixOnly bool              // the variables are only consulted outside Windows
+	}{
+		{
+			name: "set",
+			set:  map[string]string{"PHP_RUNNER_VERSION": "8.1", "PHP_RUNNER_PIN": "1"},
+			want: []string{"PHP_RUNNER_VERSION=8.1\n", "PHP_RUNNER_PIN=1\n"},
+		},
+		{
+			name: "unset",
+			want: []string{"PHP_RUNNER_VERSION= (unset)", "PHP_RUNNER_CONFIG= (unset)", "PHP_RUNNER_VERBOSE= (unset)"},
+		},
+		{
+			name:     "home unset",
+			unset:    []string{"HOME"},
+			want:     []string{"HOME= (unset)"},
+			unixOnly: true,
+		},
+		{
+			name:  "extra",
+			set:   map[string]string{"PHP_RUNNER_ZZZ": "z", "PHP_RUNNER_AAA": "a"},
+			want:  []string{"PHP_RUNNER_AAA=a\n", "PHP_RUNNER_ZZZ=z\n"},
+			order: []string{"PHP_RUNNER_VERSION=", "PHP_RUNNER_AAA=a", "PHP_RUNNER_ZZZ=z", "HOME="},
+		},
+		{
+			name:     "config home set",
+			set:      map[string]string{"XDG_CONFIG_HOME": "/tmp/xdg"},
+			want:     []string{"XDG_CONFIG_HOME=/tmp/xdg\n", "HOME="},
+			unixOnly: true,
+		},
+		{
+			name:     "config home unset",
+			unset:    []string{"XDG_CONFIG_HOME"},
+			want:     []string{"XDG_CONFIG_HOME= (unset)"},
+			unixOnly: true,
+		},
+		{
+			name:     "php on PATH",
+			path:     "php",
+			want:     []string{"PHP_RUNNER_PATH_PHP={bin}/php\n"},
+			unixOnly: true,
+		},
+		{
+			name: "no php on PATH",
+			path: "-",
+			want: []string{"PHP_RUNNER_PATH_PHP= (not found)\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.unixOnly && runtime.GOOS == "windows" {
+				t.Skip("the config locations come from other variables on Windows")
+			}
+			isolate(t)
+			for name, value := range tt.set {
+				t.Setenv(name, value)
+			}
+			for _, name := range tt.unset {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+
+			bin := t.TempDir()
+			switch tt.path {
+			case "php":
+				writeStub(t, filepath.Join(bin, "php"), "exit 0")
+				t.Setenv("PATH", bin)
+			case "-":
+				t.Setenv("PATH", bin)
+			}
+
+			stdout, stderr, code := runRunner(t, t.TempDir(), nil, "env")
+			if code != 0 {
+				t.Fatalf("env exited %d: %s", code, stderr)
+			}
+			for _, want := range tt.want {
+				want = strings.ReplaceAll(want, "{bin}", bin)
+				if !strings.HasPrefix(stdout, want) && !strings.Contains(stdout, "\n"+want) {
+					t.Errorf("output has no line starting %q:\n%s", want, stdout)
+				}
+			}
+			last := -1
+			for _, line := range tt.order {
+				i := strings.Index(stdout, line)
+				if i < last {
+					t.Errorf("%q is out of order:\n%s", line, stdout)
+				}
+				last = i
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// eolDates are the dates PHP releases stop receiving security fixes, per
+// major.minor line, as published at https://www.php.net/supported-versions
+var eolDates = map[string]string{
+	"5.6": "2018-12-31",
+	"7.0": "2019-01-10",
+	"7.1": "2019-12-01",
+	"7.2": "2020-11-30",
+	"7.3": "2021-12-06",
+	"7.4": "2022-11-28",
+	"8.0": "2023-11-26",
+	"8.1": "2025-12-31",
+	"8.2": "2026-12-31",
+	"8.3": "2027-12-31",
+	"8.4": "2028-12-31",
+}
+
+// eolDate returns the end-of-life date of a version, preferring an eol
+// setting for the version key, then for its major.minor line, then the
+// built-in table. ok is false if the date isn't known.
+func eolDate(config *Config, version string) (date time.Time, ok bool, err error) {
+	line := version
+	if parts, numeric := parseVersionParts(versionKeyRe.FindString(version)); numeric && len(parts) >= 2 {
+		line = formatVersionParts(parts[:2])
+	}
+
+	text, ok := config.EOLDates[version]
+	if !ok {
+		text, ok = config.EOLDates[line]
+	}
+	if !ok {
+		text, ok = eolDates[line]
+	}
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	date, err = time.Parse(time.DateOnly, text)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid end-of-life date %q for %s: expected YYYY-MM-DD", text, version)
+	}
+	return date, true, nil
+}
+
+// checkEOL returns an error if version has passed its end-of-life date
+func checkEOL(config *Config, version string, now time.Time) error {
+	date, ok, err := eolDate(config, version)
+	if err != nil || !ok {
+		return err
+	}
+	if now.After(date) {
+		return fmt.Errorf("PHP %s reached end of life on %s; upgrade it or run without --block-eol", version, date.Format(time.DateOnly))
+	}
+	return nil
+}
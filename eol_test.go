@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckEOL(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		version string
+		eol     map[string]string // eol settings
+		wantErr string
+	}{
+		{version: "7.4", wantErr: "PHP 7.4 reached end of life on 2022-11-28"},
+		{version: "8.1.27", wantErr: "PHP 8.1.27 reached end of life on 2025-12-31"},
+		{version: "8.3"},
+		{version: "8.2-zts"},
+		// Versions missing from the table aren't blocked
+		{version: "9.0"},
+		{version: "custom"},
+		{version: "8.3", eol: map[string]string{"8.3": "2026-01-01"}, wantErr: "reached end of life on 2026-01-01"},
+		{version: "7.4", eol: map[string]string{"7.4": "2030-01-01"}},
+		// The version key wins over its line
+		{version: "8.2.5", eol: map[string]string{"8.2": "2020-01-01", "8.2.5": "2030-01-01"}},
+		{version: "8.2.5", eol: map[string]string{"8.2": "2020-01-01"}, wantErr: "reached end of life on 2020-01-01"},
+	}
+	for _, tt := range tests {
+		config := newConfig()
+		for version, date := range tt.eol {
+			config.EOLDates[version] = date
+		}
+		err := checkEOL(config, tt.version, now)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("checkEOL(%s, %v) = %v, want %q", tt.version, tt.eol, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBlockEOL(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		config   string // extra config lines
+		args     []string
+		want     string
+		wantCode int
+	}{
+		{name: "blocked", version: "7.4", args: []string{"--block-eol"}, wantCode: exitUnavailable},
+		{name: "supported", version: "8.4", config: "eol.8.4: 2999-12-31\n", args: []string{"--block-eol"}, want: "ran\n"},
+		{name: "not blocking", version: "7.4", want: "ran\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php"+tt.version), "echo ran")
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), tt.version+": "+php+"\n"+tt.config)
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, append(tt.args, "x.php")...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout, tt.want)
+			}
+			if blocked := strings.Contains(stderr, "reached end of life"); blocked != (tt.wantCode != 0) {
+				t.Errorf("stderr = %q", stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// execPHP can't replace the process without exec(2), so PHP runs as a child
+// of php-runner as before
+func execPHP(cmd *exec.Cmd) error {
+	return errors.ErrUnsupported
+}
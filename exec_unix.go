@@ -0,0 +1,18 @@
+//go:build unix
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// execPHP replaces php-runner with the command, so PHP keeps php-runner's
+// process ID and its signals and exit status reach the caller directly. It
+// only returns if the exec fails.
+func execPHP(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	return syscall.Exec(cmd.Path, cmd.Args, cmd.Env)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Outcomes whose exit codes can be changed with "exit_code.<outcome>" in the
+// config or --exit-code <outcome>=<code>
+const (
+	outcomeTimeout          = "timeout"           // PHP was killed by --timeout
+	outcomeSignal           = "signal"            // PHP was killed by a signal
+	outcomeUsage            = "usage"             // php-runner's own flags are invalid
+	outcomeConfigNotFound   = "config-not-found"  // no config file exists
+	outcomeConfigInvalid    = "config-invalid"    // a config file exists but can't be loaded
+	outcomeCwdMissing       = "cwd-missing"       // the current directory can't be determined
+	outcomeVersionUnmatched = "version-unmatched" // no configured version could be selected
+	outcomePHPUnavailable   = "php-unavailable"   // the selected PHP is missing, refused or won't start
+	outcomeEOL              = "eol"               // --block-eol refused an end-of-life version
+	outcomeFileError        = "file-error"        // a file given to php-runner can't be opened or written
+)
+
+// exitOutcomes lists the outcomes in the order they are documented
+var exitOutcomes = []string{
+	outcomeTimeout, outcomeSignal, outcomeUsage, outcomeConfigNotFound, outcomeConfigInvalid, outcomeCwdMissing,
+	outcomeVersionUnmatched, outcomePHPUnavailable, outcomeEOL, outcomeFileError,
+}
+
+// Exit codes for failing to get as far as running PHP, from sysexits.h, so
+// scripts can tell them apart from PHP's own exit status
+const (
+	exitUsage       = 64 // EX_USAGE: php-runner was invoked wrongly
+	exitUnavailable = 69 // EX_UNAVAILABLE: no usable PHP for the project
+	exitCantCreate  = 73 // EX_CANTCREAT: a file it was asked to use can't be opened
+	exitConfig      = 78 // EX_CONFIG: the configuration is missing or broken
+)
+
+// defaultExitCodes are the codes used unless overridden. A signal exits
+// with 128 plus the signal number, as shells report it, unless overridden.
+var defaultExitCodes = map[string]int{
+	outcomeTimeout:          124, // as GNU timeout
+	outcomeUsage:            exitUsage,
+	outcomeConfigNotFound:   exitConfig,
+	outcomeConfigInvalid:    exitConfig,
+	outcomeCwdMissing:       exitUnavailable,
+	outcomeVersionUnmatched: exitUnavailable,
+	outcomePHPUnavailable:   exitUnavailable,
+	outcomeEOL:              exitUnavailable,
+	outcomeFileError:        exitCantCreate,
+}
+
+// errConfigNotFound is wrapped by the error returned when no config file
+// exists, as opposed to one that exists but can't be loaded
+var errConfigNotFound = errors.New("config file not found")
+
+// exitCodeFor returns the exit code for an outcome: --exit-code wins over the
+// config, which wins over the default. config may be nil if it isn't loaded.
+func exitCodeFor(config *Config, outcome string) int {
+	if code, ok := opts.exitCodes[outcome]; ok {
+		return code
+	}
+	if config != nil {
+		if code, ok := config.ExitCodes[outcome]; ok {
+			return code
+		}
+	}
+	return defaultExitCodes[outcome]
+}
+
+// signalExitCode returns the exit code for PHP being killed by signal
+func signalExitCode(config *Config, signal int) int {
+	if _, overridden := opts.exitCodes[outcomeSignal]; overridden {
+		return exitCodeFor(config, outcomeSignal)
+	}
+	if config != nil {
+		if code, ok := config.ExitCodes[outcomeSignal]; ok {
+			return code
+		}
+	}
+	return 128 + signal
+}
+
+// isBrokenPipe reports whether err comes from writing to a pipe whose
+// reader has exited
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
+
+// parseExitCode validates an outcome name and its exit code
+func parseExitCode(outcome, value string) (int, error) {
+	if !slices.Contains(exitOutcomes, outcome) {
+		return 0, fmt.Errorf("unknown outcome %q: expected %s", outcome, strings.Join(exitOutcomes, ", "))
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil || code < 0 || code > 255 {
+		return 0, fmt.Errorf("invalid exit code %q for %s: expected 0-255", value, outcome)
+	}
+	return code, nil
+}
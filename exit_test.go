@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		outcome      string
+		script       string // the PHP stub, or "" for one that can't be started
+		config       string // extra config lines
+		env          []string
+		args         []string // with {{root}} for the temp root
+		deletedCwd   bool
+		configurable bool // whether exit_code settings apply, i.e. the config loads first
+		want         int  // the default code
+	}{
+		{outcome: outcomeTimeout, script: "exec sleep 5", args: []string{"--timeout", "200ms", "x.php"}, configurable: true, want: 124},
+		{outcome: outcomeSignal, script: "kill -TERM $$", args: []string{"--timeout", "1m", "x.php"}, configurable: true, want: 128 + 15},
+		{outcome: outcomeUsage, script: "exit 0", args: []string{"--timeout", "soon", "x.php"}, want: exitUsage},
+		{outcome: outcomeConfigNotFound, script: "exit 0", env: []string{"PHP_RUNNER_CONFIG={{root}}/missing.yaml"}, args: []string{"x.php"}, want: exitConfig},
+		{outcome: outcomeConfigInvalid, script: "exit 0", config: "rule: nope\n", args: []string{"x.php"}, want: exitConfig},
+		{outcome: outcomeCwdMissing, script: "exit 0", args: []string{"x.php"}, deletedCwd: true, configurable: true, want: exitUnavailable},
+		{outcome: outcomeVersionUnmatched, script: "exit 0", env: []string{versionEnvVar + "=9.9"}, args: []string{"x.php"}, configurable: true, want: exitUnavailable},
+		{outcome: outcomePHPUnavailable, args: []string{"x.php"}, configurable: true, want: exitUnavailable},
+		{outcome: outcomeEOL, script: "exit 0", config: "eol.8.2: 2000-01-01\n", args: []string{"--block-eol", "x.php"}, configurable: true, want: exitUnavailable},
+		{outcome: outcomeFileError, script: "exit 0", args: []string{"--stdout", "{{root}}/missing/x.log", "x.php"}, configurable: true, want: exitCantCreate},
+	}
+	for _, tt := range tests {
+		for _, mode := range []string{"default", "config", "flag"} {
+			if mode == "config" && !tt.configurable {
+				continue
+			}
+			t.Run(tt.outcome+"/"+mode, func(t *testing.T) {
+				isolate(t)
+				root := t.TempDir()
+				php := filepath.Join(root, "php8.2")
+				if tt.script != "" {
+					writeStub(t, php, tt.script)
+				} else {
+					// Passes the loader's checks, but its interpreter can't be run
+					interpreter := writeFile(t, filepath.Join(root, "interpreter"), "")
+					writeFile(t, php, "#!"+interpreter+"\n")
+					if err := os.Chmod(php, 0755); err != nil {
+						t.Fatal(err)
+					}
+				}
+				config := "8.2: " + php + "\n" + tt.config
+				want, args := tt.want, tt.args
+				switch mode {
+				case "config":
+					config += "exit_code." + tt.outcome + ": 3\n"
+					want = 3
+				case "flag":
+					args = append([]string{"--exit-code", tt.outcome + "=4"}, args...)
+					want = 4
+				}
+				configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+				replacer := strings.NewReplacer("{{root}}", root)
+				env := []string{"PHP_RUNNER_CONFIG=" + configPath}
+				for _, value := range tt.env {
+					env = append(env, replacer.Replace(value))
+				}
+				for i := range args {
+					args[i] = replacer.Replace(args[i])
+				}
+
+				var output string
+				var code int
+				if tt.deletedCwd {
+					output, code = runRunnerInDeletedDir(t, env, args...)
+				} else {
+					var stdout, stderr string
+					stdout, stderr, code = runRunner(t, root, env, args...)
+					output = stdout + stderr
+				}
+				if code != want {
+					t.Errorf("exit code = %d, want %d: %s", code, want, output)
+				}
+			})
+		}
+	}
+}
+
+func TestChildExitCode(t *testing.T) {
+	// Every outcome overridden, to check none of them touches PHP's own code
+	var overrides string
+	for _, outcome := range exitOutcomes {
+		overrides += "exit_code." + outcome + ": 3\n"
+	}
+	modes := []struct {
+		name   string
+		args   []string
+		config string
+	}{
+		{name: "exec"},
+		{name: "supervised", args: []string{"--timeout", "1m"}},
+		{name: "overridden", config: overrides},
+		{name: "supervised and overridden", args: []string{"--timeout", "1m"}, config: overrides},
+	}
+	// Including PHP codes that php-runner also uses for its own outcomes
+	codes := []int{0, 1, 2, exitUsage, exitUnavailable, exitConfig, 124, 128 + 15, 255}
+	for _, mode := range modes {
+		for _, want := range codes {
+			t.Run(fmt.Sprintf("%s/%d", mode.name, want), func(t *testing.T) {
+				isolate(t)
+				root := t.TempDir()
+				php := writeStub(t, filepath.Join(root, "php8.2"), fmt.Sprintf("exit %d", want))
+				configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+php+"\n"+mode.config)
+
+				args := append(append([]string{}, mode.args...), "x.php")
+				_, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, args...)
+				if code != want {
+					t.Errorf("exit code = %d, want PHP's %d: %s", code, want, stderr)
+				}
+			})
+		}
+	}
+}
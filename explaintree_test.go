@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string // files under the repository
+		start string            // directory the search starts in
+		// walked directories from the highest down, relative to the
+		// repository, and what was found there
+		want [][2]string
+	}{
+		{
+			name:  "pin at the root",
+			files: map[string]string{".git/HEAD": "", versionFile: "8.2\n"},
+			start: "a/b/c",
+			want: [][2]string{
+				{".", "found 8.2"},
+				{"a", "no " + versionFile},
+				{"a/b", "no " + versionFile},
+				{"a/b/c", "no " + versionFile},
+			},
+		},
+		{
+			name:  "nearest pin",
+			files: map[string]string{".git/HEAD": "", versionFile: "8.1\n", "a/" + versionFile: "8.2\n"},
+			start: "a/b",
+			want: [][2]string{
+				{"a", "found 8.2"},
+				{"a/b", "no " + versionFile},
+			},
+		},
+		{
+			name:  "empty pin skipped",
+			files: map[string]string{".git/HEAD": "", versionFile: "8.2\n", "a/" + versionFile: "\n"},
+			start: "a/b",
+			want: [][2]string{
+				{".", "found 8.2"},
+				{"a", "empty " + versionFile + ", skipped"},
+				{"a/b", "no " + versionFile},
+			},
+		},
+		{
+			name:  "stopped at the project root",
+			files: map[string]string{".git/HEAD": ""},
+			start: "a/b",
+			want: [][2]string{
+				{".", "no " + versionFile + ", project root, search stopped"},
+				{"a", "no " + versionFile},
+				{"a/b", "no " + versionFile},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n"
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			repo := filepath.Join(root, "repo")
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(repo, filepath.FromSlash(name)), content)
+			}
+			start := filepath.Join(repo, filepath.FromSlash(tt.start))
+			if err := os.MkdirAll(start, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			_, stderr, code := runRunner(t, start, nil, "--explain-tree", "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			lines := strings.Split(stderr, "\n")
+			header := versionFile + " search from " + start + ":"
+			if len(lines) == 0 || lines[0] != header {
+				t.Fatalf("stderr = %q, want it to start with %q", stderr, header)
+			}
+			// The top directory is shown in full, the rest by name
+			// indented under their parent
+			if len(lines) < len(tt.want)+1 {
+				t.Fatalf("stderr = %q, want %d directories", stderr, len(tt.want))
+			}
+			for depth, step := range tt.want {
+				line := lines[depth+1]
+				dir := filepath.Join(repo, filepath.FromSlash(step[0]))
+				name := dir
+				if depth > 0 {
+					name = strings.Repeat("    ", depth-1) + "└── " + filepath.Base(dir)
+				}
+				if !strings.HasPrefix(line, "  "+name+" ") || !strings.HasSuffix(line, " "+step[1]) {
+					t.Errorf("line %d = %q, want %s: %s", depth+1, line, name, step[1])
+				}
+			}
+		})
+	}
+}
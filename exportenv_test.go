@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionEnvName(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"8.2", "PHP_RUNNER_V_8_2"},
+		{"8.2.15", "PHP_RUNNER_V_8_2_15"},
+		{"8.2-zts", "PHP_RUNNER_V_8_2_ZTS"},
+		{"8.3-RC1", "PHP_RUNNER_V_8_3_RC1"},
+		{"7.4 custom/build", "PHP_RUNNER_V_7_4_CUSTOM_BUILD"},
+	}
+	for _, tt := range tests {
+		if got := versionEnvName(tt.version); got != tt.want {
+			t.Errorf("versionEnvName(%q) = %s, want %s", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestExportEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string // versions given stub binaries, in "it's here"
+		args     []string
+		// lines printed in version order, with {{bin}} for the stub directory
+		want    []string
+		wantErr string
+	}{
+		{
+			name:     "plain",
+			versions: []string{"8.1", "8.2-zts"},
+			want:     []string{"PHP_RUNNER_V_8_1={{bin}}/php8.1", "PHP_RUNNER_V_8_3=docker://php:8.3-cli", "PHP_RUNNER_V_8_2_ZTS={{bin}}/php8.2-zts"},
+		},
+		{
+			name:     "shell escaped",
+			versions: []string{"8.1"},
+			args:     []string{"--shell-escape"},
+			want:     []string{`PHP_RUNNER_V_8_1='{{quoted}}/php8.1'`, "PHP_RUNNER_V_8_3=docker://php:8.3-cli"},
+		},
+		{
+			name:     "names collide",
+			versions: []string{"8.1", "8_1"},
+			wantErr:  "versions 8.1 and 8_1 would both be exported as PHP_RUNNER_V_8_1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			bin := filepath.Join(root, "it's here")
+			config := "8.3: docker://php:8.3-cli\n"
+			for _, version := range tt.versions {
+				config += version + ": " + writeStub(t, filepath.Join(bin, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+
+			stdout, stderr, code := runRunner(t, root, nil, append([]string{"export-env"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if code == 0 || !strings.Contains(stderr, tt.wantErr) || stdout != "" {
+					t.Errorf("export-env exited %d with %q, %q, want only the error %q", code, stdout, stderr, tt.wantErr)
+				}
+				return
+			}
+			if code != 0 {
+				t.Fatalf("export-env exited %d: %s", code, stderr)
+			}
+			replacer := strings.NewReplacer("{{bin}}", bin, "{{quoted}}", strings.ReplaceAll(bin, "'", `'\''`))
+			want := replacer.Replace(strings.Join(tt.want, "\n") + "\n")
+			if stdout != want {
+				t.Errorf("stdout = %q, want %q", stdout, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseModules(t *testing.T) {
+	output := "[PHP Modules]\nCore\ndate\njson\n\n[Zend Modules]\nZend OPcache\nCore\n"
+	want := []string{"Core", "date", "json", "Zend OPcache"}
+	if got := parseModules(output); !slices.Equal(got, want) {
+		t.Errorf("parseModules = %q, want %q", got, want)
+	}
+}
+
+func TestExtDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string // "php -m" module lines of 8.1
+		to   string // and of 8.2
+		want string
+	}{
+		{
+			name: "added and removed",
+			from: "Core\nmcrypt\njson",
+			to:   "Core\njson\nrandom\nsodium",
+			want: "+ random\n+ sodium\n- mcrypt\n",
+		},
+		{
+			name: "case differs only",
+			from: "Core\nZend OPcache",
+			to:   "core\nzend opcache",
+			want: "PHP 8.1 and 8.2 load the same extensions\n",
+		},
+		{
+			name: "removed only",
+			from: "Core\n[Zend Modules]\nXdebug",
+			to:   "Core",
+			want: "- Xdebug\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := ""
+			for version, modules := range map[string]string{"8.1": tt.from, "8.2": tt.to} {
+				script := "printf '[PHP Modules]\\n" + strings.ReplaceAll(modules, "\n", "\\n") + "\\n'"
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), script) + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+
+			stdout, stderr, code := runRunner(t, root, nil, "ext-diff", "8.1", "8.2")
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("output:\n%s\nwant:\n%s", stdout, tt.want)
+			}
+		})
+	}
+}
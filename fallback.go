@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"os"
+	"runtime"
+)
+
+// fallbackConfigs holds a built-in config per platform listing the usual
+// install locations of versioned PHP binaries
+//
+//go:embed fallback/*.yaml
+var fallbackConfigs embed.FS
+
+// loadFallbackConfig returns the built-in config for this platform, limited
+// to the versions whose executables exist, or nil if there are none
+func loadFallbackConfig() *Config {
+	data, err := fallbackConfigs.ReadFile("fallback/" + runtime.GOOS + ".yaml")
+	if err != nil {
+		return nil
+	}
+	return parseFallbackConfig(data)
+}
+
+// parseFallbackConfig loads a built-in config, keeping only the versions
+// whose executables exist. When a version has several candidate locations
+// the first existing one is used.
+func parseFallbackConfig(data []byte) *Config {
+	entries, err := parseConfigEntries(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	// Drop missing locations up front so they aren't warned about
+	var installed []configEntry
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if _, err := os.Stat(entry.Value); err != nil || seen[entry.Key] {
+			continue
+		}
+		seen[entry.Key] = true
+		installed = append(installed, entry)
+	}
+	if len(installed) == 0 {
+		return nil
+	}
+
+	config, err := configFromEntries(installed)
+	if err != nil {
+		return nil
+	}
+	return config
+}
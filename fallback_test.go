@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFallbackConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // with {{root}} for the temp root
+		want    map[string]string
+	}{
+		{
+			name:    "first existing location",
+			content: "8.1: {{root}}/missing/php8.1\n8.1: {{root}}/a/php8.1\n8.1: {{root}}/b/php8.1\n8.2: {{root}}/missing/php8.2\n",
+			want:    map[string]string{"8.1": "{{root}}/a/php8.1"},
+		},
+		{
+			name:    "nothing installed",
+			content: "8.1: {{root}}/missing/php8.1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			writeStub(t, filepath.Join(root, "a", "php8.1"), "exit 0")
+			writeStub(t, filepath.Join(root, "b", "php8.1"), "exit 0")
+			replacer := strings.NewReplacer("{{root}}", root)
+
+			config := parseFallbackConfig([]byte(replacer.Replace(tt.content)))
+			if tt.want == nil {
+				if config != nil {
+					t.Errorf("config = %v, want none", config.Versions)
+				}
+				return
+			}
+			if config == nil {
+				t.Fatal("config = nil")
+			}
+			if len(config.Versions) != len(tt.want) {
+				t.Errorf("versions = %v, want %v", config.Versions, tt.want)
+			}
+			for version, path := range tt.want {
+				if got := config.Versions[version]; got != filepath.FromSlash(replacer.Replace(path)) {
+					t.Errorf("%s = %s, want %s", version, got, replacer.Replace(path))
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedFallbackConfigs(t *testing.T) {
+	names, err := fs.Glob(fallbackConfigs, "fallback/*.yaml")
+	if err != nil || len(names) == 0 {
+		t.Fatalf("embedded configs = %v, %v", names, err)
+	}
+	for _, name := range names {
+		data, _ := fallbackConfigs.ReadFile(name)
+		entries, err := parseConfigEntries(strings.NewReader(string(data)))
+		if err != nil || len(entries) == 0 {
+			t.Errorf("%s: %d entries, %v", name, len(entries), err)
+		}
+		for _, entry := range entries {
+			if _, ok := parseVersionParts(entry.Key); !ok {
+				t.Errorf("%s line %d: %q is not a version", name, entry.Line, entry.Key)
+			}
+		}
+	}
+}
+
+func TestFallbackLastResort(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     bool // write a config in the user's config directory
+		explicit bool // name a missing config in PHP_RUNNER_CONFIG
+	}{
+		{name: "user config", user: true},
+		{name: "explicit config missing", explicit: true},
+		{name: "no config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php7.0"), "exit 0")
+			if tt.user {
+				writeFile(t, filepath.Join(root, "xdg", "php-runner", configFileName), "7.0: "+php+"\n")
+				t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+			}
+			if tt.explicit {
+				t.Setenv("PHP_RUNNER_CONFIG", filepath.Join(root, "missing.yaml"))
+			} else if !tt.user {
+				if paths, err := findConfigFiles(); err == nil {
+					t.Skipf("this system has a config in %s", paths[0])
+				}
+			}
+			fallback := loadFallbackConfig()
+
+			config, err := loadRunnerConfig()
+			switch {
+			case tt.user:
+				// A config file wins even when the fallback would find PHP
+				if err != nil || len(config.Versions) != 1 || config.Versions["7.0"] != php {
+					t.Errorf("loadRunnerConfig = %v, %v, want only the user's 7.0", config, err)
+				}
+			case tt.explicit || fallback == nil:
+				if !errors.Is(err, errConfigNotFound) {
+					t.Errorf("loadRunnerConfig error = %v, want config not found", err)
+				}
+			default:
+				if err != nil || len(config.Versions) != len(fallback.Versions) {
+					t.Errorf("loadRunnerConfig = %v, %v, want the built-in config %v", config, err, fallback.Versions)
+				}
+			}
+		})
+	}
+}
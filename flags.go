@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// options holds php-runner's own command line flags
+type options struct {
+	platformCheck bool           // warn if Composer's platform check is not satisfied
+	githubOutput  bool           // write the resolution to $GITHUB_OUTPUT
+	envFile       string         // dotenv file to write the resolution to
+	probeTimeout  time.Duration  // how long to wait for "php --version"
+	onMissing     string         // what to do when a pinned version isn't configured
+	realPath      bool           // search for project files from the cwd's real path
+	noFileSearch  bool           // ignore .php-version and other project files
+	pin           bool           // write a .php-version recording a fallback choice
+	versionPath   bool           // put the resolved binary's directory first on PHP's PATH
+	ideaDetect    bool           // also read the language level from PhpStorm's .idea/php.xml
+	ddev          bool           // also read the php_version from DDEV's .ddev/config.yaml
+	alternatives  bool           // also use the version update-alternatives selects for php
+	explainTree   bool           // draw the .php-version search on stderr
+	verifyVersion string         // "warn" or "error" if the binary's real version must match its key
+	stdinFile     string         // file to feed to PHP's stdin
+	stdoutFile    string         // file to send PHP's stdout to
+	stderrFile    string         // file to send PHP's stderr to
+	appendOutput  bool           // append to the output files instead of truncating them
+	stderrTail    int            // lines of PHP's stderr to keep for failure reports
+	quietWarnings string         // "process" or "persist" to print each warning only once
+	cacheStats    bool           // print the cache counters instead of running PHP
+	cacheReset    bool           // zero the cache counters instead of printing them
+	timeout       time.Duration  // kill PHP if it runs longer than this
+	exitCodes     map[string]int // exit codes given with --exit-code, by outcome
+	sources       []string       // resolution sources in priority order, if given
+	byPath        string         // select the version installed under this path
+	blockEOL      bool           // refuse to run versions past their end of life
+	secure        bool           // refuse to run a binary other users could replace
+	consistency   bool           // refuse a pin that composer.json's PHP requirement rejects
+	lockExact     bool           // require exactly the PHP version composer.lock was locked for
+	workspaceMin  bool           // use the lowest version every composer.json in the workspace allows
+	pathMismatch  bool           // warn if the php on PATH isn't the resolved version
+	verbose       bool           // report progress and resolution steps on stderr
+	configFile    string         // config file to load instead of searching for one
+	checksum      bool           // refuse a config whose content doesn't match its checksum
+	traceExec     bool           // print the command and environment PHP is run with
+	dryRun        bool           // print the command PHP would be run with instead of running it
+	redact        []string       // name patterns of variables --trace-exec hides
+	versionArg    string         // a leading "PHP=<version>" argument choosing this run's version
+
+	showDeprecations bool // list deprecated versions instead of running PHP
+	listFiles        bool // list the version files around the cwd instead of running PHP
+	benchmarkRuns    int  // time this many runs of every version instead of running PHP
+}
+
+const defaultProbeTimeout = 2 * time.Second
+
+// defaultBenchmarkRuns is how many timed runs --benchmark-versions makes
+const defaultBenchmarkRuns = 10
+
+// Values for --on-missing
+const (
+	onMissingFallback    = "fallback"     // try the next source, as before
+	onMissingInstallHint = "install-hint" // fail and suggest how to install it
+)
+
+var opts = options{
+	probeTimeout: defaultProbeTimeout,
+	onMissing:    onMissingFallback,
+	exitCodes:    make(map[string]int),
+	verbose:      envEnabled("PHP_RUNNER_VERBOSE"),
+	pin:          envEnabled("PHP_RUNNER_PIN"),
+}
+
+// parseRunnerFlags consumes php-runner's own flags from the front of args and
+// returns the remaining arguments, which belong to PHP. Parsing stops at the
+// first argument that isn't a php-runner flag, so anything after a script
+// name (or an unknown flag such as PHP's --version) is passed on untouched.
+// A "--" separator is left at the front of the result for main to strip.
+func parseRunnerFlags(args []string) ([]string, error) {
+	for len(args) > 0 && args[0] != "--" {
+		name, value, inline := strings.Cut(args[0], "=")
+		consumed := 1
+
+		// flagValue returns the value given as "--name=value" or as the next argument
+		flagValue := func() (string, error) {
+			if inline {
+				return value, nil
+			}
+			if len(args) < 2 {
+				return "", fmt.Errorf("%s requires a value", name)
+			}
+			consumed = 2
+			return args[1], nil
+		}
+		// noValue rejects "--name=value" for flags that don't take one
+		noValue := func() error {
+			if inline {
+				return fmt.Errorf("%s does not take a value", name)
+			}
+			return nil
+		}
+
+		var err error
+		switch name {
+		case "PHP", "PHP_VERSION":
+			// "PHP=8.1 script.php" picks the version for this run, as
+			// make's VAR=value does; a bare word is PHP's
+			if !inline {
+				return args, nil
+			}
+			if value == "" {
+				err = fmt.Errorf("%s= requires a version", name)
+			}
+			opts.versionArg = args[0]
+		case "--trace-exec":
+			err = noValue()
+			opts.traceExec = true
+		case "--dry-run":
+			err = noValue()
+			opts.dryRun = true
+		case "--redact":
+			var pattern string
+			if pattern, err = flagValue(); err == nil {
+				if _, matchErr := path.Match(pattern, ""); matchErr != nil {
+					err = fmt.Errorf("invalid %s pattern %q: %v", name, pattern, matchErr)
+				}
+				opts.redact = append(opts.redact, pattern)
+			}
+		case "--config":
+			opts.configFile, err = flagValue()
+		case "--verify-checksum":
+			err = noValue()
+			opts.checksum = true
+		case "--platform-check":
+			err = noValue()
+			opts.platformCheck = true
+		case "--github-output":
+			err = noValue()
+			opts.githubOutput = true
+		case "--print-resolved-env-file":
+			opts.envFile, err = flagValue()
+		case "--append-version-to-path":
+			err = noValue()
+			opts.versionPath = true
+		case "--pin":
+			err = noValue()
+			opts.pin = true
+		case "--no-version-file-search":
+			err = noValue()
+			opts.noFileSearch = true
+		case "--show-deprecations":
+			err = noValue()
+			opts.showDeprecations = true
+		case "--explain-tree":
+			err = noValue()
+			opts.explainTree = true
+		case "--benchmark-versions":
+			// A bare flag times the default number of runs
+			opts.benchmarkRuns = defaultBenchmarkRuns
+			if inline {
+				runs, parseErr := strconv.Atoi(value)
+				if parseErr != nil || runs < 1 {
+					err = fmt.Errorf("invalid %s %q: expected a number of runs", name, value)
+				}
+				opts.benchmarkRuns = runs
+			}
+		case "--list-files":
+			err = noValue()
+			opts.listFiles = true
+		case "--alternatives":
+			err = noValue()
+			opts.alternatives = true
+		case "--idea-detect":
+			err = noValue()
+			opts.ideaDetect = true
+		case "--ddev":
+			err = noValue()
+			opts.ddev = true
+		case "--resolve-symlinks":
+			err = noValue()
+			opts.realPath = true
+		case "--quiet-warnings-once":
+			// A bare flag dedups within this run; "=persist" across runs too
+			opts.quietWarnings = quietProcess
+			if inline {
+				if value != quietProcess && value != quietPersist {
+					err = fmt.Errorf("invalid %s %q: expected %s or %s", name, value, quietProcess, quietPersist)
+				}
+				opts.quietWarnings = value
+			}
+		case "--resolve-cache-stats":
+			err = noValue()
+			opts.cacheStats = true
+		case "--reset":
+			err = noValue()
+			opts.cacheReset = true
+		case "--verify-version":
+			// A bare flag warns; "--verify-version=error" refuses to run
+			opts.verifyVersion = "warn"
+			if inline {
+				if value != "warn" && value != "error" {
+					err = fmt.Errorf("invalid %s %q: expected warn or error", name, value)
+				}
+				opts.verifyVersion = value
+			}
+		case "--stdin-file":
+			opts.stdinFile, err = flagValue()
+		case "--stdout":
+			opts.stdoutFile, err = flagValue()
+		case "--stderr":
+			opts.stderrFile, err = flagValue()
+		case "--append":
+			err = noValue()
+			opts.appendOutput = true
+		case "--stderr-tail":
+			var raw string
+			if raw, err = flagValue(); err == nil {
+				lines, parseErr := strconv.Atoi(raw)
+				if parseErr != nil || lines < 0 {
+					err = fmt.Errorf("invalid %s %q: expected a number of lines", name, raw)
+				}
+				opts.stderrTail = lines
+			}
+		case "--resolve-timeout":
+			var raw string
+			if raw, err = flagValue(); err == nil {
+				timeout, parseErr := time.ParseDuration(raw)
+				if parseErr != nil || timeout <= 0 {
+					err = fmt.Errorf("invalid %s %q: expected a duration such as 2s", name, raw)
+				}
+				opts.probeTimeout = timeout
+			}
+		case "--timeout":
+			var raw string
+			if raw, err = flagValue(); err == nil {
+				timeout, parseErr := time.ParseDuration(raw)
+				if parseErr != nil || timeout <= 0 {
+					err = fmt.Errorf("invalid %s %q: expected a duration such as 10m", name, raw)
+				}
+				opts.timeout = timeout
+			}
+		case "--exit-code":
+			// "--exit-code <outcome>=<code>", repeated for each outcome
+			var raw string
+			if raw, err = flagValue(); err == nil {
+				outcome, code, _ := strings.Cut(raw, "=")
+				var exitCode int
+				if exitCode, err = parseExitCode(outcome, code); err == nil {
+					opts.exitCodes[outcome] = exitCode
+				}
+			}
+		case "--verbose":
+			err = noValue()
+			opts.verbose = true
+		case "--warn-path-mismatch":
+			err = noValue()
+			opts.pathMismatch = true
+		case "--workspace-min":
+			err = noValue()
+			opts.workspaceMin = true
+		case "--lock-exact":
+			err = noValue()
+			opts.lockExact = true
+		case "--enforce-consistency":
+			err = noValue()
+			opts.consistency = true
+		case "--block-eol":
+			err = noValue()
+			opts.blockEOL = true
+		case "--secure":
+			err = noValue()
+			opts.secure = true
+		case "--by-path":
+			opts.byPath, err = flagValue()
+		case "--sources":
+			var raw string
+			if raw, err = flagValue(); err == nil {
+				opts.sources, err = parseSourceOrder(raw)
+			}
+		case "--on-missing":
+			var mode string
+			if mode, err = flagValue(); err == nil {
+				if mode != onMissingFallback && mode != onMissingInstallHint {
+					err = fmt.Errorf("invalid %s %q: expected %s or %s", name, mode, onMissingFallback, onMissingInstallHint)
+				}
+				opts.onMissing = mode
+			}
+		default:
+			return args, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = args[consumed:]
+	}
+	return args, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRunnerFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		want        []string // the arguments left for PHP
+		wantVerbose bool
+		wantConfig  string
+	}{
+		{name: "flag after script", args: []string{"x.php", "--config", "app.yaml"}, want: []string{"x.php", "--config", "app.yaml"}},
+		{name: "runner flag then script", args: []string{"--verbose", "x.php", "--verbose"}, want: []string{"x.php", "--verbose"}, wantVerbose: true},
+		{name: "php flag stops parsing", args: []string{"--version", "--verbose"}, want: []string{"--version", "--verbose"}},
+		{name: "separator kept for main", args: []string{"--config", "a.yaml", "--", "--config"}, want: []string{"--", "--config"}, wantConfig: "a.yaml"},
+		{name: "version argument", args: []string{"PHP=8.1", "x.php", "--pin"}, want: []string{"x.php", "--pin"}},
+		{name: "bare PHP word", args: []string{"PHP", "--verbose"}, want: []string{"PHP", "--verbose"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			got, err := parseRunnerFlags(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PHP arguments = %q, want %q", got, tt.want)
+			}
+			if opts.verbose != tt.wantVerbose || opts.configFile != tt.wantConfig || opts.pin {
+				t.Errorf("verbose, config, pin = %v, %q, %v, want %v, %q, false", opts.verbose, opts.configFile, opts.pin, tt.wantVerbose, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestFlagsAfterScriptForwarded(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	php := writeStub(t, filepath.Join(root, "php8.2"), `printf '%s\n' "$@"`)
+	writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "8.2: "+php+"\n")
+	writeFile(t, filepath.Join(root, versionFile), "8.2\n")
+
+	tests := [][]string{
+		{"x.php", "--on-missing", "install-hint"},
+		{"x.php", "--version", "--platform-check"},
+		{"x.php", "--", "--resolve-timeout=1s"},
+		{"--", "list"},
+	}
+	for _, args := range tests {
+		stdout, stderr, code := runRunner(t, root, nil, args...)
+		want := args
+		if args[0] == "--" {
+			want = args[1:]
+		}
+		if got := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n"); code != 0 || !slices.Equal(got, want) {
+			t.Errorf("%q: PHP got %q (exit %d, %s), want %q", args, got, code, stderr, want)
+		}
+	}
+}
+
+func TestVersionAssignment(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     []string
+		version string   // the version that should run
+		want    []string // the arguments it should get
+		errMsg  string   // or the error the run fails with
+		code    int
+	}{
+		{name: "PHP", args: []string{"PHP=8.1", "x.php"}, version: "8.1", want: []string{"x.php"}},
+		{name: "PHP_VERSION", args: []string{"PHP_VERSION=8.1", "x.php", "-d", "a=1"}, version: "8.1", want: []string{"x.php", "-d", "a=1"}},
+		{name: "after a runner flag", args: []string{"--verbose", "PHP=8.1", "x.php"}, version: "8.1", want: []string{"x.php"}},
+		{name: "outranks the environment", args: []string{"PHP=8.1", "x.php"}, env: []string{versionEnvVar + "=8.2"}, version: "8.1", want: []string{"x.php"}},
+		{name: "alias", args: []string{"PHP=latest", "x.php"}, version: "8.2", want: []string{"x.php"}},
+		{name: "after the script", args: []string{"x.php", "PHP=8.1"}, version: "8.3", want: []string{"x.php", "PHP=8.1"}},
+		{name: "other assignment", args: []string{"APP_ENV=test", "x.php"}, version: "8.3", want: []string{"APP_ENV=test", "x.php"}},
+		{
+			name:   "unconfigured version",
+			args:   []string{"PHP=8.0", "x.php"},
+			errMsg: "PHP=8.0 is not a configured version (available: 8.1, 8.2, 8.3)",
+			code:   exitUnavailable,
+		},
+		{name: "empty version", args: []string{"PHP=", "x.php"}, errMsg: "PHP= requires a version", code: exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), `echo `+version+`; printf '%s\n' "$@"`) + "\n"
+			}
+			config += "alias.latest: 8.2\n"
+			configPath := writeFile(t, filepath.Join(root, configFileName), config)
+			writeFile(t, filepath.Join(root, versionFile), "8.3\n")
+
+			stdout, stderr, code := runRunner(t, root, append([]string{"PHP_RUNNER_CONFIG=" + configPath}, tt.env...), tt.args...)
+			if tt.errMsg != "" {
+				if code != tt.code || !strings.Contains(stdout+stderr, tt.errMsg) {
+					t.Errorf("exited %d with %q, want %d and %q", code, stdout+stderr, tt.code, tt.errMsg)
+				}
+				return
+			}
+			want := append([]string{tt.version}, tt.want...)
+			if got := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n"); code != 0 || !slices.Equal(got, want) {
+				t.Errorf("PHP printed %q (exit %d, %s), want %q", got, code, stderr, want)
+			}
+		})
+	}
+}
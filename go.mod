@@ -2,4 +2,4 @@ module php-runner
 
 go 1.23.1
 
-require gopkg.in/yaml.v2 v2.4.0 // indirect
+require gopkg.in/yaml.v2 v2.4.0
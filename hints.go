@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// defaultInstallHints are the suggested install commands per platform;
+// "{version}" is replaced with the missing version
+var defaultInstallHints = map[string]string{
+	"darwin":  "brew install php@{version}",
+	"linux":   "sudo apt install php{version}-cli",
+	"windows": "download PHP {version} from https://windows.php.net/download/",
+}
+
+// missingVersionError reports a version requested by a project file that
+// isn't configured
+type missingVersionError struct {
+	Version string // the requested version
+	File    string // the file that requested it
+	Nearest string // the configured version it was most likely meant to be
+	Hint    string // how to install it, if known
+}
+
+func (e *missingVersionError) Error() string {
+	msg := fmt.Sprintf("PHP version %s requested by %s is not configured", e.Version, e.File)
+	if e.Nearest != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", e.Nearest)
+	}
+	if e.Hint != "" {
+		msg += "\nTo install it: " + e.Hint
+	}
+	return msg
+}
+
+// installHint returns the install suggestion for version on the current
+// platform
+func installHint(config *Config, version string) string {
+	return platformInstallHint(config, version, runtime.GOOS)
+}
+
+// platformInstallHint returns the install suggestion for version on goos. A
+// hint configured for the version wins over one for the platform, which
+// wins over the built-in hint.
+func platformInstallHint(config *Config, version, goos string) string {
+	hint, ok := config.InstallHints[version]
+	if !ok {
+		hint, ok = config.InstallHints[goos]
+	}
+	if !ok {
+		hint = defaultInstallHints[goos]
+	}
+	return strings.ReplaceAll(hint, "{version}", version)
+}
+
+// maxSuggestDistance is how many single-character edits a requested version
+// may be from a configured one for it to be suggested
+const maxSuggestDistance = 1
+
+// nearestVersion returns the configured version closest to version by edit
+// distance, so a typo such as "8.20" suggests "8.2", or "" if none is close.
+// Ties go to the numerically nearest, then the higher version.
+func nearestVersion(config *Config, version string) string {
+	requested, _ := parseVersionParts(versionKeyRe.FindString(version))
+	best, bestDistance := "", maxSuggestDistance+1
+	var bestGap int
+	for _, candidate := range configuredVersions(config) {
+		distance := editDistance(version, candidate)
+		parts, _ := parseVersionParts(versionKeyRe.FindString(candidate))
+		gap := versionGap(requested, parts)
+		if distance < bestDistance || distance == bestDistance && gap <= bestGap {
+			best, bestDistance, bestGap = candidate, distance, gap
+		}
+	}
+	return best
+}
+
+// versionGap measures how far apart two versions are numerically, comparing
+// the first component that differs
+func versionGap(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return max(a[i]-b[i], b[i]-a[i])
+		}
+	}
+	return 0
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to yes. It
+// reads the answer a byte at a time so none of the input meant for PHP is
+// consumed. Without a terminal to ask on, the answer is no.
+func confirm(question string) bool {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", question)
+	var answer []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil || n == 0 || buf[0] == '\n' {
+			break
+		}
+		answer = append(answer, buf[0])
+	}
+	switch strings.ToLower(strings.TrimSpace(string(answer))) {
+	case "", "y", "yes":
+		return true
+	}
+	return false
+}
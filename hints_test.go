@@ -0,0 +1,175 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlatformInstallHint(t *testing.T) {
+	tests := []struct {
+		name  string
+		hints map[string]string // install_hint settings
+		goos  string
+		want  string
+	}{
+		{name: "darwin default", goos: "darwin", want: "brew install php@8.4"},
+		{name: "linux default", goos: "linux", want: "sudo apt install php8.4-cli"},
+		{name: "windows default", goos: "windows", want: "download PHP 8.4 from https://windows.php.net/download/"},
+		{name: "unknown platform", goos: "plan9", want: ""},
+		{
+			name:  "platform setting",
+			hints: map[string]string{"darwin": "brew install shivammathur/php/php@{version}"},
+			goos:  "darwin",
+			want:  "brew install shivammathur/php/php@8.4",
+		},
+		{
+			name:  "version setting wins",
+			hints: map[string]string{"linux": "apt install php{version}", "8.4": "build {version} from source"},
+			goos:  "linux",
+			want:  "build 8.4 from source",
+		},
+		{
+			name:  "other platform's setting ignored",
+			hints: map[string]string{"darwin": "brew install shivammathur/php/php@{version}"},
+			goos:  "linux",
+			want:  "sudo apt install php8.4-cli",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newConfig()
+			for key, hint := range tt.hints {
+				config.InstallHints[key] = hint
+			}
+			if got := platformInstallHint(config, "8.4", tt.goos); got != tt.want {
+				t.Errorf("hint = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForcedVersionInstallHint(t *testing.T) {
+	tests := []struct {
+		name      string
+		onMissing string
+		wantHint  bool
+	}{
+		{name: "fallback", onMissing: onMissingFallback},
+		{name: "install-hint", onMissing: onMissingInstallHint, wantHint: true},
+	}
+	for _, tt := range tests {
+		for _, source := range []struct{ name, source string }{{versionEnvVar, sourceEnv}, {"PHP", sourceArgument}} {
+			t.Run(tt.name+"/"+source.name, func(t *testing.T) {
+				isolate(t)
+				opts.onMissing = tt.onMissing
+				config := newConfig()
+				config.Versions["8.2"] = "/usr/bin/php8.2"
+				config.InstallHints["8.4"] = "build {version} from source"
+
+				_, err := resolveForcedVersion(config, source.name, "8.4", source.source)
+				if err == nil {
+					t.Fatal("an unconfigured version was accepted")
+				}
+				if !strings.HasPrefix(err.Error(), source.name+"=8.4 is not a configured version (available: 8.2)") {
+					t.Errorf("error = %q", err)
+				}
+				if hinted := strings.Contains(err.Error(), "To install it: build 8.4 from source"); hinted != tt.wantHint {
+					t.Errorf("error = %q, want hint: %v", err, tt.wantHint)
+				}
+			})
+		}
+	}
+}
+
+func TestNearestVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "8.20", want: "8.2"},
+		{version: "82", want: "8.2"},
+		{version: "8.11", want: "8.1"},
+		{version: "9.1", want: "8.1"},
+		// As close to 8.2 as to 8.4, so the higher wins
+		{version: "8.3", want: "8.4"},
+		{version: "8.5", want: "8.4"},
+		{version: "7.0"},
+		{version: "8.2.10.1"},
+	}
+	for _, tt := range tests {
+		config := newConfig()
+		for _, version := range []string{"8.1", "8.2", "8.4"} {
+			config.Versions[version] = "/usr/bin/php" + version
+		}
+		if got := nearestVersion(config, tt.version); got != tt.want {
+			t.Errorf("nearestVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestNearestVersionSuggestion(t *testing.T) {
+	tests := []struct {
+		name      string
+		pin       string
+		onMissing string
+		warning   string // the suggestion warned about, if any
+		errMsg    string // or the error resolution fails with
+	}{
+		{
+			name:      "fallback",
+			pin:       "8.20",
+			onMissing: onMissingFallback,
+			warning:   "names PHP 8.20, which is not configured; did you mean 8.2?",
+		},
+		{
+			name:      "install-hint",
+			pin:       "8.20",
+			onMissing: onMissingInstallHint,
+			errMsg:    "PHP version 8.20 requested by {pin} is not configured (did you mean 8.2?)",
+		},
+		{name: "nothing near", pin: "5.6", onMissing: onMissingFallback},
+		{
+			name:      "nothing near with install-hint",
+			pin:       "5.6",
+			onMissing: onMissingInstallHint,
+			errMsg:    "PHP version 5.6 requested by {pin} is not configured\nTo install it:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			opts.onMissing = tt.onMissing
+			config := newConfig()
+			config.Versions["8.2"] = "/usr/bin/php8.2"
+			config.Versions["8.3"] = "/usr/bin/php8.3"
+			project := t.TempDir()
+			pin := writeFile(t, filepath.Join(project, versionFile), tt.pin+"\n")
+
+			// Without a terminal the suggestion isn't offered, only reported
+			var version string
+			var err error
+			stderr := captureStderr(t, func() { version, err = versionFileSource(project, project, config) })
+			if tt.errMsg != "" {
+				want := strings.ReplaceAll(tt.errMsg, "{pin}", pin)
+				if err == nil {
+					t.Fatalf("resolved %q, want an error", version)
+				}
+				if !strings.HasPrefix(err.Error(), want) {
+					t.Errorf("error = %q, want %q", err, want)
+				}
+				return
+			}
+			if err != nil || version != tt.pin {
+				t.Errorf("versionFileSource = %q, %v, want the unconfigured %s for the next source to handle", version, err, tt.pin)
+			}
+			if tt.warning == "" {
+				if stderr != "" {
+					t.Errorf("stderr = %q, want no suggestion", stderr)
+				}
+			} else if !strings.Contains(stderr, pin+" "+tt.warning) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.warning)
+			}
+		})
+	}
+}
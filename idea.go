@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+)
+
+// ideaProjectFile is where PhpStorm keeps the project's PHP settings
+var ideaProjectFile = filepath.Join(".idea", "php.xml")
+
+// ideaProject is the part of .idea/php.xml php-runner reads, e.g.
+//
+//	<project version="4">
+//	  <component name="PhpProjectSharedConfiguration" php_language_level="8.1" />
+//	</project>
+type ideaProject struct {
+	Components []struct {
+		Name          string `xml:"name,attr"`
+		LanguageLevel string `xml:"php_language_level,attr"`
+	} `xml:"component"`
+}
+
+// findIdeaVersion looks for a PhpStorm .idea/php.xml in the current and
+// parent directories, up to the search boundary if bounded, and returns the
+// PHP language level it sets
+func findIdeaVersion(startDir string, bounded bool) (string, string) {
+	var version, ideaPath string
+	walkUp(startDir, bounded, func(dir string) bool {
+		ideaPath = filepath.Join(dir, ideaProjectFile)
+		version = readIdeaVersion(ideaPath)
+		return version != ""
+	})
+	if version == "" {
+		return "", ""
+	}
+	return version, ideaPath
+}
+
+// readIdeaVersion returns the language level from a PhpStorm php.xml, or ""
+// if the file is missing, malformed or doesn't set one
+func readIdeaVersion(ideaPath string) string {
+	data, err := os.ReadFile(ideaPath)
+	if err != nil {
+		return ""
+	}
+
+	var project ideaProject
+	if err := xml.Unmarshal(data, &project); err != nil {
+		return ""
+	}
+	for _, component := range project.Components {
+		if component.Name == "PhpProjectSharedConfiguration" {
+			return component.LanguageLevel
+		}
+	}
+	return ""
+}
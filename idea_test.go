@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const samplePhpXML = `<?xml version="1.0" encoding="UTF-8"?>
+<project version="4">
+  <component name="MessDetectorOptionsConfiguration">
+    <option name="transferred" value="true" />
+  </component>
+  <component name="PhpProjectSharedConfiguration" php_language_level="8.1">
+    <option name="suggestChangeDefaultLanguageLevel" value="false" />
+  </component>
+  <component name="PhpUnit">
+    <phpunit_settings>
+      <PhpUnitSettings custom_loader_path="$PROJECT_DIR$/vendor/autoload.php" />
+    </phpunit_settings>
+  </component>
+</project>
+`
+
+func TestReadIdeaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "language level", content: samplePhpXML, want: "8.1"},
+		{name: "no language level", content: `<project version="4"><component name="PhpProjectSharedConfiguration" /></project>`},
+		{name: "other components only", content: `<project version="4"><component name="PhpUnit" php_language_level="7.4" /></project>`},
+		{name: "malformed", content: `<project version="4"><component`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), ideaProjectFile), tt.content)
+			if got := readIdeaVersion(path); got != tt.want {
+				t.Errorf("readIdeaVersion = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdeaDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		detect bool
+		want   string
+	}{
+		{name: "opted in", detect: true, want: "8.1"},
+		{name: "not opted in", want: "8.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, "project")
+			writeFile(t, filepath.Join(project, ideaProjectFile), samplePhpXML)
+			// Found from a subdirectory too
+			src := filepath.Join(project, "src")
+			writeFile(t, filepath.Join(src, "index.php"), "")
+
+			args := []string{"current"}
+			if tt.detect {
+				args = append([]string{"--idea-detect"}, args...)
+			}
+			stdout, stderr, code := runRunner(t, src, nil, args...)
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIniScanDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string // extra config lines
+		inherit  string // PHP_INI_SCAN_DIR in php-runner's environment
+		want     string
+	}{
+		{name: "configured", settings: "ini_scan_dir.8.2: /etc/php/8.2/conf.d\n", want: "/etc/php/8.2/conf.d"},
+		{name: "replaces inherited", settings: "ini_scan_dir.8.2: /etc/php/8.2/conf.d\n", inherit: "/etc/other", want: "/etc/php/8.2/conf.d"},
+		{name: "other version's ignored", settings: "ini_scan_dir.8.1: /etc/php/8.1/conf.d\n", inherit: "/etc/other", want: "/etc/other"},
+		{name: "env setting wins", settings: "ini_scan_dir.8.2: /etc/php/8.2/conf.d\nenv.8.2: PHP_INI_SCAN_DIR=/srv/conf.d\n", want: "/srv/conf.d"},
+	}
+	for _, tt := range tests {
+		// PHP is exec'd in place of php-runner unless something needs supervising
+		for _, mode := range []struct{ name, flag string }{{"exec", ""}, {"supervised", "--timeout=1m"}} {
+			t.Run(tt.name+"/"+mode.name, func(t *testing.T) {
+				isolate(t)
+				root := t.TempDir()
+				php := writeStub(t, filepath.Join(root, "php8.2"), `printf '%s' "$PHP_INI_SCAN_DIR"`)
+				php81 := writeStub(t, filepath.Join(root, "php8.1"), "exit 0")
+				writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "8.1: "+php81+"\n8.2: "+php+"\n"+tt.settings)
+				writeFile(t, filepath.Join(root, versionFile), "8.2\n")
+
+				args := []string{"x.php"}
+				if mode.flag != "" {
+					args = append([]string{mode.flag}, args...)
+				}
+				stdout, stderr, code := runRunner(t, root, []string{"PHP_INI_SCAN_DIR=" + tt.inherit}, args...)
+				if code != 0 {
+					t.Fatalf("exit code %d: %s", code, stderr)
+				}
+				if strings.TrimSpace(stdout) != tt.want {
+					t.Errorf("PHP saw PHP_INI_SCAN_DIR=%q, want %q", stdout, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPermissionDeniedLaunch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a script interpreter that isn't executable")
+	}
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "exec", args: []string{"x.php"}},
+		{name: "supervised", args: []string{"--stderr-tail", "5", "x.php"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			// The binary passes the loader's checks, but the kernel refuses to
+			// run the interpreter it names
+			interpreter := writeFile(t, filepath.Join(root, "interpreter"), "")
+			php := writeFile(t, filepath.Join(root, "php8.2"), "#!"+interpreter+"\n")
+			if err := os.Chmod(php, 0755); err != nil {
+				t.Fatal(err)
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+php+"\n")
+
+			_, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, tt.args...)
+			if code != exitUnavailable {
+				t.Errorf("exit code = %d, want %d", code, exitUnavailable)
+			}
+			want := "Error executing PHP 8.2 (" + php + "): "
+			if !strings.Contains(stderr, want) || !strings.Contains(stderr, "permission denied") {
+				t.Errorf("stderr = %q, want %q and the permission error", stderr, want)
+			}
+			// PHP never started, so it wrote nothing to show
+			if strings.Contains(stderr, "Last lines") {
+				t.Errorf("stderr = %q, want no stderr tail", stderr)
+			}
+		})
+	}
+}
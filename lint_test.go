@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintConfigEntries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the sample paths are Unix install locations")
+	}
+	tests := []struct {
+		name    string
+		content string // with {{home}} for the home directory
+		want    []string
+	}{
+		{
+			name:    "clean",
+			content: "file_mode: 0644\n8.2: /usr/bin/php8.2\n8.2-zts: /opt/php/8.2-zts/bin/php\n8.3.1: {{home}}/.phpenv/versions/8.3.1/bin/php\n8.4: docker://php:8.4-cli\n",
+		},
+		{
+			name:    "version form",
+			content: "8: /usr/bin/php8\nlatest: /usr/bin/php-latest\n",
+			want:    []string{`1: version "8" is not of the form major.minor[.patch]`, `2: version "latest" is not of the form major.minor[.patch]`},
+		},
+		{
+			name:    "not a PHP executable",
+			content: "8.2: /usr/bin/python3\n8.3: /usr/local/bin/php-cgi.exe\n",
+			want:    []string{"1: /usr/bin/python3 doesn't look like a PHP executable"},
+		},
+		{
+			name:    "duplicate path",
+			content: "8.2: /usr/bin/php\n8.3: /usr/bin/../bin/php\n",
+			want:    []string{"2: /usr/bin/../bin/php is also configured as version 8.2"},
+		},
+		{
+			name:    "outside install roots",
+			content: "8.2: /srv/php82/bin/php\n",
+			want:    []string{"1: /srv/php82/bin/php is outside the usual install locations"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			home, _ := os.UserHomeDir()
+			content := strings.ReplaceAll(tt.content, "{{home}}", home)
+			entries, err := parseConfigEntries(strings.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := lintConfigEntries(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("lint = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigLintStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		args     []string
+		want     string // after the config path
+		wantCode int
+	}{
+		{name: "clean", content: "8.2: /usr/bin/php8.2\n", want: ": no problems found\n"},
+		{name: "advisory", content: "8.2: /usr/bin/python3\n", want: ":1: /usr/bin/python3 doesn't look like a PHP executable\n"},
+		{name: "strict", content: "8.2: /usr/bin/python3\n", args: []string{"--strict"}, want: ":1: /usr/bin/python3 doesn't look like a PHP executable\n", wantCode: 1},
+		{name: "strict and clean", content: "8.2: /usr/bin/php8.2\n", args: []string{"--strict"}, want: ": no problems found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" {
+				t.Skip("the sample paths are Unix install locations")
+			}
+			isolate(t)
+			root := t.TempDir()
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), tt.content)
+
+			stdout, stderr, code := runRunner(t, root, nil, append([]string{"config", "lint"}, tt.args...)...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if want := configPath + tt.want; stdout != want {
+				t.Errorf("stdout = %q, want %q", stdout, want)
+			}
+		})
+	}
+}
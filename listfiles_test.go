@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string // search_boundary setting, or "-" for no config file
+		wantOuter bool   // whether files above the repository are listed
+	}{
+		{name: "default boundary", config: "", wantOuter: false},
+		{name: "no boundary", config: "search_boundary: none\n", wantOuter: true},
+		{name: "no config", config: "-", wantOuter: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			outer := writeFile(t, filepath.Join(root, versionFile), "7.4\n")
+			repo := filepath.Join(root, "repo")
+			if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			inner := writeFile(t, filepath.Join(repo, "composer.json"), `{"require": {"php": "^8.2"}}`)
+			toolVersions := writeFile(t, filepath.Join(repo, ".tool-versions"), "nodejs 20.1.0\nphp 8.1.2\n")
+			start := filepath.Join(repo, "src")
+			if err := os.MkdirAll(start, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			var env []string
+			if tt.config != "-" {
+				php := writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+				configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+php+"\n"+tt.config)
+				env = append(env, "PHP_RUNNER_CONFIG="+configPath)
+			}
+
+			stdout, stderr, code := runRunner(t, start, env, "--list-files")
+			if code != 0 {
+				t.Fatalf("--list-files exited %d: %s", code, stderr)
+			}
+			if !strings.Contains(stdout, inner+": requires php ^8.2") {
+				t.Errorf("output doesn't list %s:\n%s", inner, stdout)
+			}
+			if !strings.Contains(stdout, toolVersions+": 8.1.2 (not used for resolution)\n") {
+				t.Errorf("output doesn't mark %s as unused:\n%s", toolVersions, stdout)
+			}
+			if listed := strings.Contains(stdout, outer); listed != tt.wantOuter {
+				t.Errorf("%s listed: %v, want %v:\n%s", outer, listed, tt.wantOuter, stdout)
+			}
+		})
+	}
+}
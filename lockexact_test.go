@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockExact(t *testing.T) {
+	tests := []struct {
+		name   string
+		lock   string // composer.lock in the project, if any
+		args   []string
+		want   string // the version current prints
+		errMsg string // or the error it fails with
+	}{
+		{
+			name: "exact version present",
+			lock: `{"platform-overrides": {"php": "8.1.27"}}`,
+			args: []string{"--lock-exact"},
+			want: "8.1.27",
+		},
+		{
+			name:   "exact version absent",
+			lock:   `{"platform-overrides": {"php": "8.1.30"}}`,
+			args:   []string{"--lock-exact"},
+			errMsg: "is locked to PHP 8.1.30, which is not configured exactly (nearest: 8.1, 8.1.27)",
+		},
+		{
+			name:   "no version shares the minor",
+			lock:   `{"platform-overrides": {"php": "7.4.33"}}`,
+			args:   []string{"--lock-exact"},
+			errMsg: "is locked to PHP 7.4.33, which is not configured exactly (nearest: 8.1, 8.1.27, 8.3)",
+		},
+		{
+			name: "lock without an override",
+			lock: `{"packages": []}`,
+			args: []string{"--lock-exact"},
+			want: "8.3",
+		},
+		{
+			name: "no lock",
+			args: []string{"--lock-exact"},
+			want: "8.3",
+		},
+		{
+			name: "without --lock-exact",
+			lock: `{"platform-overrides": {"php": "8.1.27"}}`,
+			want: "8.3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.1.27: " + writeStub(t, filepath.Join(root, "php8.1.27"), "exit 0") + "\n" +
+				"8.3: " + writeStub(t, filepath.Join(root, "php8.3"), "exit 0") + "\n"
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			project := filepath.Join(root, "project")
+			writeFile(t, filepath.Join(project, "composer.json"), `{"require": {"php": ">=8.0"}}`)
+			if tt.lock != "" {
+				writeFile(t, filepath.Join(project, "composer.lock"), tt.lock)
+			}
+			// Run from a subdirectory so the lock is found by walking up
+			dir := filepath.Dir(writeFile(t, filepath.Join(project, "src", "x.php"), "<?php\n"))
+
+			args := append(append([]string{}, tt.args...), "current")
+			stdout, stderr, code := runRunner(t, dir, []string{"PHP_RUNNER_CONFIG=" + configPath}, args...)
+			if tt.errMsg != "" {
+				if code == 0 || !strings.Contains(stderr, tt.errMsg) {
+					t.Errorf("exited %d with %q, want a failure with %q", code, stderr, tt.errMsg)
+				}
+				return
+			}
+			if code != 0 {
+				t.Fatalf("exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
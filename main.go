@@ -2,17 +2,73 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
-type Config map[string]string
+// runnerVersion is php-runner's own release, injected at build time with
+// -ldflags "-X main.runnerVersion=1.4.0"; development builds report "dev"
+var runnerVersion = "dev"
+
+// Config holds the versions and settings read from php-runner.yaml
+type Config struct {
+	Versions     map[string]string   // version -> PHP executable path
+	Missing      map[string]string   // version -> configured path that doesn't exist or can't be run
+	IniScanDirs  map[string]string   // version -> PHP_INI_SCAN_DIR for that version
+	Env          map[string][]string // version -> extra NAME=value variables for PHP
+	Args         map[string][]string // version -> arguments passed to PHP before the user's
+	InstallHints map[string]string   // version or platform -> install command
+	Aliases      map[string]string   // alias such as "stable" -> version it stands for
+	Deprecations map[string]string   // version -> why it is being sunset
+	EOLDates     map[string]string   // version -> end-of-life date overriding the built-in one
+	TypeDefaults map[string]string   // project type -> version used when nothing pins one
+	FileMode     os.FileMode         // permissions for created .php-version files
+	Default      string              // version used when nothing else selects one
+	Resolver     string              // script that prints the version for a directory
+	Policy       string              // file of version constraints per project type
+	MinRunner    string              // oldest php-runner release that understands this config
+	Boundary     string              // where the .php-version search stops: "project" or "none"
+	Rules        []versionRule       // directory patterns mapped to versions, in file order
+	ExitCodes    map[string]int      // outcome -> exit code overriding the default
+	Sources      []string            // resolution sources in priority order, if not the default
+}
+
+// versionRule selects a version for directories whose path matches Pattern
+type versionRule struct {
+	Pattern *regexp.Regexp
+	Version string
+}
+
+// newConfig returns an empty Config ready to be filled by loadConfig
+func newConfig() *Config {
+	return &Config{
+		Versions:     make(map[string]string),
+		Missing:      make(map[string]string),
+		IniScanDirs:  make(map[string]string),
+		Env:          make(map[string][]string),
+		Args:         make(map[string][]string),
+		InstallHints: make(map[string]string),
+		Aliases:      make(map[string]string),
+		Deprecations: make(map[string]string),
+		ExitCodes:    make(map[string]int),
+		EOLDates:     make(map[string]string),
+		TypeDefaults: make(map[string]string),
+	}
+}
 
 const (
 	configFileName = "php-runner.yaml"
@@ -21,63 +77,418 @@ const (
 )
 
 func main() {
-	// Load configuration
-	configPath, err := findConfigFile()
+	args, err := parseRunnerFlags(os.Args[1:]) // Skip the program name
 	if err != nil {
-		fmt.Printf("Error finding config file: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCodeFor(nil, outcomeUsage))
+	}
+	if opts.cacheReset && !opts.cacheStats {
+		fmt.Fprintln(os.Stderr, "Error: --reset only applies to --resolve-cache-stats")
+		os.Exit(exitCodeFor(nil, outcomeUsage))
+	}
+
+	// "--" ends php-runner's arguments: everything after it goes to PHP
+	// untouched, even a word that names one of php-runner's subcommands
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	} else if len(args) > 0 {
+		// Run php-runner's own subcommands instead of passing them to PHP
+		if handler := subcommand(args[0]); handler != nil {
+			os.Exit(handler(args[1:]))
+		}
 	}
 
-	config, err := loadConfig(configPath)
+	// Listing version files doesn't need a config, so works with a broken
+	// one; the search_boundary is honored only if the config loads
+	if opts.listFiles {
+		cwd, err := workingDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(exitCodeFor(nil, outcomeCwdMissing))
+		}
+		bounded := true
+		if config, err := loadRunnerConfig(); err == nil {
+			bounded = searchBounded(config)
+		}
+		os.Exit(listVersionFiles(projectSearchDir(cwd), bounded))
+	}
+
+	// Load configuration
+	config, err := loadRunnerConfig()
 	if err != nil {
-		fmt.Printf("Error loading config from %s: %v\n", configPath, err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		if errors.Is(err, errConfigNotFound) {
+			os.Exit(exitCodeFor(nil, outcomeConfigNotFound))
+		}
+		os.Exit(exitCodeFor(nil, outcomeConfigInvalid))
 	}
 
-	// Get current working directory
-	cwd, err := os.Getwd()
+	if opts.showDeprecations {
+		os.Exit(showDeprecations(config))
+	}
+	if opts.cacheStats {
+		os.Exit(showCacheStats(opts.cacheReset))
+	}
+
+	// Get current working directory; only needed from here on, so commands
+	// handled above keep working even if it has been deleted
+	cwd, err := workingDir()
 	if err != nil {
-		fmt.Printf("Error getting current directory: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(exitCodeFor(config, outcomeCwdMissing))
+	}
+
+	if opts.benchmarkRuns > 0 {
+		os.Exit(benchmarkVersions(config, cwd, opts.benchmarkRuns))
 	}
 
 	// Get PHP version to use
-	version := getPhpVersion(cwd, config)
+	resolution := getPhpVersion(cwd, config)
+	version := resolution.Version
 
 	// Get PHP executable path
-	phpPath, exists := config[version]
+	phpPath, exists := config.Versions[version]
 	if !exists {
-		fmt.Printf("PHP version %s not found in configuration\n", version)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "PHP version %s not found in configuration\n", version)
+		os.Exit(exitCodeFor(config, outcomeVersionUnmatched))
+	}
+
+	if message, deprecated := config.Deprecations[version]; deprecated {
+		warnf("PHP %s is deprecated: %s", version, message)
 	}
 
-	// Check if PHP executable exists
-	if _, err := os.Stat(phpPath); os.IsNotExist(err) {
-		fmt.Printf("PHP executable not found at: %s\n", phpPath)
-		os.Exit(1)
+	if opts.pathMismatch {
+		checkPathMismatch(version)
 	}
 
-	// Execute PHP with all arguments
-	args := os.Args[1:] // Skip the program name
-	cmd := exec.Command(phpPath, args...)
+	if opts.blockEOL {
+		if err := checkEOL(config, version, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCodeFor(config, outcomeEOL))
+		}
+	}
+
+	// Check if PHP executable exists; container images are pulled on demand
+	_, _, isContainer := parseContainerPath(phpPath)
+	if _, err := os.Stat(phpPath); !isContainer && os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "PHP executable not found at: %s\n", phpPath)
+		os.Exit(exitCodeFor(config, outcomePHPUnavailable))
+	}
+
+	if opts.secure && !isContainer {
+		if err := checkSecureBinary(phpPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: refusing to run PHP %s under --secure: %v\n", version, err)
+			os.Exit(exitCodeFor(config, outcomePHPUnavailable))
+		}
+	}
+
+	// Container images are not probed, only local binaries
+	if opts.verifyVersion != "" && !isContainer {
+		if err := verifyBinaryVersion(version, phpPath); err != nil {
+			if opts.verifyVersion == "error" {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitCodeFor(config, outcomePHPUnavailable))
+			}
+			warnf("%v", err)
+		}
+	}
+
+	if opts.platformCheck {
+		checkPlatformRequirement(cwd, version, searchBounded(config))
+	}
+
+	if opts.githubOutput {
+		if err := writeGithubOutput(version, phpPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing GitHub output: %v\n", err)
+			os.Exit(exitCodeFor(config, outcomeFileError))
+		}
+	}
+	if opts.envFile != "" {
+		if err := writeEnvFile(opts.envFile, resolution, phpPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", opts.envFile, err)
+			os.Exit(exitCodeFor(config, outcomeFileError))
+		}
+	}
+	// Without PHP arguments these flags only record the resolution
+	if (opts.githubOutput || opts.envFile != "") && len(args) == 0 {
+		os.Exit(0)
+	}
+
+	// Execute PHP with all remaining arguments
+	cmd, err := phpCommand(config, version, cwd, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCodeFor(config, outcomePHPUnavailable))
+	}
+
+	// A dry run stops before opening any of PHP's files or starting it
+	if opts.dryRun {
+		if opts.traceExec {
+			traceExec(os.Stderr, cmd, opts.redact)
+		}
+		printCommand(os.Stdout, cmd)
+		os.Exit(0)
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	err = cmd.Run()
+	// Feed PHP's stdin from a file; it is closed when php-runner exits
+	if opts.stdinFile != "" {
+		if cmd.Stdin, err = os.Open(opts.stdinFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot open stdin file: %v\n", err)
+			os.Exit(exitCodeFor(config, outcomeFileError))
+		}
+	}
+
+	// Redirect PHP's output to files; they are closed when php-runner exits
+	if opts.stdoutFile != "" {
+		if cmd.Stdout, err = openOutputFile(opts.stdoutFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCodeFor(config, outcomeFileError))
+		}
+	}
+	if opts.stderrFile != "" {
+		if opts.stderrFile == opts.stdoutFile {
+			cmd.Stderr = cmd.Stdout
+		} else if cmd.Stderr, err = openOutputFile(opts.stderrFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCodeFor(config, outcomeFileError))
+		}
+	}
+
+	if opts.traceExec {
+		traceExec(os.Stderr, cmd, opts.redact)
+	}
+
+	// With nothing to supervise, hand the process over to PHP rather than
+	// waiting on it as a parent
+	if !needsSupervision(config) {
+		if err := execPHP(cmd); !errors.Is(err, errors.ErrUnsupported) {
+			fmt.Fprintf(os.Stderr, "Error executing PHP %s (%s): %v\n", version, phpPath, err)
+			os.Exit(exitCodeFor(config, outcomePHPUnavailable))
+		}
+	}
+
+	// Keep the end of PHP's stderr to explain a failure to run it
+	var stderrTail *tailWriter
+	if opts.stderrTail > 0 {
+		stderrTail = &tailWriter{lines: opts.stderrTail}
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderrTail)
+
+		// php-runner now copies PHP's stderr itself. Go would kill it outright
+		// if the reader goes away; catching SIGPIPE turns that into a write
+		// error handled below. Unlike ignoring it, this isn't inherited by PHP.
+		signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+	}
+
+	err = cmd.Start()
+	if err == nil {
+		// Kill PHP if it outlives --timeout
+		var timedOut atomic.Bool
+		if opts.timeout > 0 {
+			timer := time.AfterFunc(opts.timeout, func() {
+				timedOut.Store(true)
+				cmd.Process.Kill()
+			})
+			defer timer.Stop()
+		}
+
+		// Relay signals meant for php-runner to PHP until it exits
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, forwardedSignals...)
+		go func() {
+			for sig := range signals {
+				forwardSignal(cmd.Process, sig)
+			}
+		}()
+		err = cmd.Wait()
+		signal.Stop(signals)
+		close(signals)
+
+		if timedOut.Load() {
+			fmt.Fprintf(os.Stderr, "PHP %s was killed after running for longer than %s\n", version, opts.timeout)
+			os.Exit(exitCodeFor(config, outcomeTimeout))
+		}
+	}
 	if err != nil {
+		// The reader of PHP's output went away, as with "| head"; there's
+		// nobody left to tell, so exit as if killed by SIGPIPE
+		if isBrokenPipe(err) {
+			os.Exit(signalExitCode(config, int(syscall.SIGPIPE)))
+		}
 		if exitError, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				os.Exit(status.ExitStatus())
+			if sig, ok := killedBy(exitError.ProcessState); ok {
+				os.Exit(signalExitCode(config, sig))
+			}
+			// ExitCode is -1 only for a process that didn't exit normally
+			if code := exitError.ExitCode(); code >= 0 {
+				os.Exit(code)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Error executing PHP %s (%s): %v\n", version, phpPath, err)
+		if stderrTail != nil {
+			if lines := stderrTail.Tail(); len(lines) > 0 {
+				fmt.Fprintf(os.Stderr, "Last lines of PHP's stderr:\n  %s\n", strings.Join(lines, "\n  "))
+			}
+		}
+		os.Exit(exitCodeFor(config, outcomePHPUnavailable))
+	}
+}
+
+// needsSupervision reports whether php-runner must stay around as PHP's
+// parent: to kill it on --timeout, to keep its stderr, to feed or capture
+// its standard streams from files, or to map its death by a signal to a
+// configured exit code
+func needsSupervision(config *Config) bool {
+	if opts.timeout > 0 || opts.stderrTail > 0 {
+		return true
+	}
+	if opts.stdinFile != "" || opts.stdoutFile != "" || opts.stderrFile != "" {
+		return true
+	}
+	_, signalCode := opts.exitCodes[outcomeSignal]
+	_, configSignalCode := config.ExitCodes[outcomeSignal]
+	return signalCode || configSignalCode
+}
+
+// childEnv returns the environment for the PHP process: php-runner's own
+// environment plus any per-version settings from the config
+func childEnv(config *Config, version string) []string {
+	env := os.Environ()
+	if scanDir := config.IniScanDirs[version]; scanDir != "" {
+		env = setEnv(env, "PHP_INI_SCAN_DIR="+scanDir)
+	}
+	for _, variable := range config.Env[version] {
+		env = setEnv(env, variable)
+	}
+
+	// Let tools PHP runs find the same version's binaries first
+	path := config.Versions[version]
+	if _, _, isContainer := parseContainerPath(path); opts.versionPath && !isContainer {
+		env = setEnv(env, "PATH="+filepath.Dir(path)+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	return env
+}
+
+// setEnv sets a NAME=value variable in env, replacing any earlier value so
+// the variable appears once even when the environment is handed straight
+// to exec
+func setEnv(env []string, variable string) []string {
+	name, _, _ := strings.Cut(variable, "=")
+	for i, existing := range env {
+		if existingName, _, _ := strings.Cut(existing, "="); existingName == name {
+			env[i] = variable
+			return env
+		}
+	}
+	return append(env, variable)
+}
+
+// phpCommand builds the command that runs a configured version with args in
+// cwd, going through the container runtime for container-backed versions
+func phpCommand(config *Config, version, cwd string, args []string) (*exec.Cmd, error) {
+	// The configured arguments come first, so the user's can override them
+	command, commandArgs := config.Versions[version], append(slices.Clone(config.Args[version]), args...)
+	if runtimeName, image, isContainer := parseContainerPath(command); isContainer {
+		var err error
+		command, commandArgs, err = containerCommand(runtimeName, image, cwd, commandArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command(command, commandArgs...)
+	cmd.Dir = cwd
+	cmd.Env = childEnv(config, version)
+	return cmd, nil
+}
+
+// workingDir returns the current directory for the commands that need it.
+// Getwd fails when the directory has been removed from under the shell, which
+// is worth spelling out since the system error alone is cryptic.
+func workingDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting current directory (has it been deleted?): %v", err)
+	}
+	return cwd, nil
+}
+
+// loadRunnerConfig finds and loads the config files
+func loadRunnerConfig() (*Config, error) {
+	configPaths, err := findConfigFiles()
+	if errors.Is(err, errConfigNotFound) && explicitConfigFile() == "" {
+		// As a last resort use the built-in config, if it finds any PHP
+		if config := loadFallbackConfig(); config != nil {
+			verbosef("no config file found, using the built-in config")
+			return config, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding config file: %w", err)
+	}
+	// State is kept with the most specific config
+	configDir = filepath.Dir(configPaths[0])
+	if opts.checksum {
+		for _, configPath := range configPaths {
+			if err := verifyConfigChecksum(configPath); err != nil {
+				return nil, err
 			}
 		}
-		fmt.Printf("Error executing PHP: %v\n", err)
-		os.Exit(1)
 	}
+
+	config, err := loadConfig(configPaths)
+	if err != nil {
+		return nil, fmt.Errorf("loading config from %s: %v", strings.Join(configPaths, ", "), err)
+	}
+	verbosef("loaded config from %s (%d versions)", strings.Join(configPaths, ", "), len(config.Versions))
+	return config, nil
+}
+
+// explicitConfigFile returns the config file given with --config or, failing
+// that, $PHP_RUNNER_CONFIG
+func explicitConfigFile() string {
+	if opts.configFile != "" {
+		return opts.configFile
+	}
+	return os.Getenv("PHP_RUNNER_CONFIG")
+}
+
+// findConfigFiles searches for php-runner.yaml in platform-specific
+// locations and returns every one that exists, most specific (the user's)
+// first, unless a config file was named explicitly
+func findConfigFiles() ([]string, error) {
+	// A config named explicitly must exist; searching elsewhere would quietly
+	// pick up a different one
+	if path := explicitConfigFile(); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("%w: %s", errConfigNotFound, path)
+		}
+		return []string{path}, nil
+	}
+
+	searchPaths := configSearchPaths()
+	var found []string
+	for _, path := range searchPaths {
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	if len(found) > 0 {
+		return found, nil
+	}
+
+	if len(searchPaths) > 0 {
+		return nil, fmt.Errorf("%w in any of these locations:\n%s", errConfigNotFound, strings.Join(searchPaths, "\n"))
+	}
+
+	return nil, fmt.Errorf("could not determine config file locations")
 }
 
-// findConfigFile searches for php-runner.yaml in platform-specific locations
-func findConfigFile() (string, error) {
+// configSearchPaths returns the places findConfigFiles looks for a config
+// file, in order
+func configSearchPaths() []string {
 	var searchPaths []string
 
 	if runtime.GOOS == "windows" {
@@ -92,8 +503,15 @@ func findConfigFile() (string, error) {
 			searchPaths = append(searchPaths, filepath.Join(programData, configFileName))
 		}
 	} else {
-		// Unix-like systems (Linux, macOS, etc.)
-		if home := os.Getenv("HOME"); home != "" {
+		// Unix-like systems (Linux, macOS, etc.), following the XDG base
+		// directory spec before the older dotfile in $HOME
+		home := os.Getenv("HOME")
+		if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+			searchPaths = append(searchPaths, filepath.Join(xdgConfig, "php-runner", configFileName))
+		} else if home != "" {
+			searchPaths = append(searchPaths, filepath.Join(home, ".config", "php-runner", configFileName))
+		}
+		if home != "" {
 			searchPaths = append(searchPaths, filepath.Join(home, "."+configFileName))
 		}
 		searchPaths = append(searchPaths, filepath.Join("/etc", configFileName))
@@ -105,32 +523,41 @@ func findConfigFile() (string, error) {
 		exeDir := filepath.Dir(exePath)
 		searchPaths = append(searchPaths, filepath.Join(exeDir, configFileName))
 	}
+	return searchPaths
+}
 
-	// Return the first existing file
-	for _, path := range searchPaths {
-		if _, err := os.Stat(path); err == nil {
-			return path, nil
-		}
-	}
-
-	// If no file found, return the first path for error messages
-	if len(searchPaths) > 0 {
-		return searchPaths[0], fmt.Errorf("config file not found in any of these locations:\n%s", strings.Join(searchPaths, "\n"))
-	}
-
-	return "", fmt.Errorf("could not determine config file locations")
+// configEntry is a single "key: value" line read from the config file
+type configEntry struct {
+	Key   string
+	Value string
+	Line  int
+	Base  string // directory a relative path in Value is taken from
 }
 
-// loadConfig loads and parses the YAML-style configuration file line by line
-func loadConfig(configPath string) (Config, error) {
-	file, err := os.Open(configPath)
+// readConfigEntries reads the configuration file and returns every entry in
+// file order, including repeated keys. Files with a top-level versions: map
+// are parsed as nested YAML, others line by line in the flat format.
+func readConfigEntries(configPath string) ([]configEntry, error) {
+	content, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open config file: %v", err)
 	}
-	defer file.Close()
+	var entries []configEntry
+	if isStructuredConfig(content) {
+		entries, err = parseStructuredConfig(content)
+	} else {
+		entries, err = parseConfigEntries(bytes.NewReader(content))
+	}
+	for i := range entries {
+		entries[i].Base = filepath.Dir(configPath)
+	}
+	return entries, err
+}
 
-	config := make(Config)
-	scanner := bufio.NewScanner(file)
+// parseConfigEntries parses configuration from r
+func parseConfigEntries(r io.Reader) ([]configEntry, error) {
+	var entries []configEntry
+	scanner := bufio.NewScanner(r)
 	lineNumber := 0
 
 	for scanner.Scan() {
@@ -148,114 +575,280 @@ func loadConfig(configPath string) (Config, error) {
 			return nil, fmt.Errorf("invalid format on line %d: %s", lineNumber, line)
 		}
 
-		version := strings.TrimSpace(parts[0])
-		path := strings.TrimSpace(parts[1])
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
 
-		if version == "" || path == "" {
+		if key == "" || value == "" {
 			return nil, fmt.Errorf("empty version or path on line %d: %s", lineNumber, line)
 		}
 
-		// Verify PHP executable exists
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			fmt.Printf("Warning: PHP executable not found at %s (line %d)\n", path, lineNumber)
-			continue // Skip invalid entries but don't fail completely
-		}
-
-		config[version] = path
+		entries = append(entries, configEntry{Key: key, Value: value, Line: lineNumber})
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %v", err)
 	}
 
-	if len(config) == 0 {
-		return nil, fmt.Errorf("no valid PHP versions found in configuration")
-	}
-
-	return config, nil
+	return entries, nil
 }
 
-// getPhpVersion determines which PHP version to use
-func getPhpVersion(cwd string, config Config) string {
-	// Look for .php-version file in current directory and parent directories
-	version := findPhpVersionFile(cwd)
-	if version != "" && config[version] != "" {
-		return version
+// loadConfig loads and merges the config files, given most specific first,
+// along with the per-version files in the versions.d directory beside each.
+// Files are applied from the least specific, and each main file after its
+// versions.d, so later entries win per key. Rules are kept in the opposite
+// order of files, so a user's rules are tried before system-wide ones.
+func loadConfig(configPaths []string) (*Config, error) {
+	var entries, rules []configEntry
+	for i := len(configPaths) - 1; i >= 0; i-- {
+		fileEntries, err := readConfigEntries(configPaths[i])
+		if err != nil {
+			return nil, err
+		}
+		dirEntries, err := readVersionsDir(filepath.Join(filepath.Dir(configPaths[i]), versionsDirName))
+		if err != nil {
+			return nil, err
+		}
+
+		var fileRules []configEntry
+		for _, entry := range append(dirEntries, fileEntries...) {
+			if entry.Key == "rule" {
+				fileRules = append(fileRules, entry)
+			} else {
+				entries = append(entries, entry)
+			}
+		}
+		rules = append(fileRules, rules...)
 	}
+	return configFromEntries(append(entries, rules...))
+}
 
-	// Get current PHP version from PATH
-	currentVersion := getCurrentPhpVersion()
-	if currentVersion != "" && config[currentVersion] != "" {
-		// Create .php-version file with current version
-		createPhpVersionFile(cwd, currentVersion)
-		return currentVersion
+// configFromEntries builds the configuration from parsed entries, skipping
+// versions whose executables don't exist or can't be run
+func configFromEntries(entries []configEntry) (*Config, error) {
+	config := newConfig()
+	// A config written for a newer php-runner may use entries this one
+	// rejects, so refuse it before validating any of the others
+	for _, entry := range entries {
+		if entry.Key == "min_runner_version" {
+			if _, err := applySetting(config, entry); err != nil {
+				return nil, err
+			}
+		}
 	}
+	if err := checkRunnerVersion(config.MinRunner); err != nil {
+		return nil, err
+	}
+
+	defined := 0
+	for _, entry := range entries {
+		if isSetting, err := applySetting(config, entry); err != nil {
+			return nil, err
+		} else if isSetting {
+			continue
+		}
+
+		defined++
+		version, path := entry.Key, expandPath(entry.Value)
 
-	// Use default version if available
-	if config[defaultVersion] != "" {
-		createPhpVersionFile(cwd, defaultVersion)
-		return defaultVersion
+		// Verify PHP executable exists; container images are pulled on demand
+		if _, _, isContainer := parseContainerPath(path); isContainer {
+			config.Versions[version] = path
+			continue
+		}
+		path = relativeTo(entry.Base, path)
+		if err := checkExecutable(path); os.IsNotExist(err) {
+			warnf("PHP executable not found at %s (line %d)", path, entry.Line)
+			config.Missing[version] = path
+			continue // Skip invalid entries but don't fail completely
+		} else if err != nil {
+			warnf("PHP executable at %s can't be run: %v (line %d)", path, err, entry.Line)
+			config.Missing[version] = path
+			continue
+		}
+
+		config.Versions[version] = path
 	}
 
-	// Use first available version from config
-	for ver := range config {
-		createPhpVersionFile(cwd, ver)
-		return ver
+	// Tell an empty config apart from one whose paths are all wrong, since
+	// they need different fixes
+	if defined == 0 {
+		return nil, fmt.Errorf("no PHP versions defined in configuration; add entries such as \"8.2: /usr/bin/php8.2\"")
+	}
+	if len(config.Versions) == 0 {
+		return nil, fmt.Errorf("none of the %d configured PHP executables exist; fix the paths reported above", defined)
 	}
 
-	fmt.Printf("No valid PHP version found\n")
-	os.Exit(1)
-	return ""
+	return config, nil
 }
 
-// findPhpVersionFile looks for .php-version file in current and parent directories
-func findPhpVersionFile(startDir string) string {
-	dir := startDir
-	for {
-		versionPath := filepath.Join(dir, versionFile)
-		if content, err := os.ReadFile(versionPath); err == nil {
-			version := strings.TrimSpace(string(content))
-			if version != "" {
-				return version
-			}
-		}
+// checkExecutable returns an error unless path, after following symlinks,
+// is a regular file that may be executed. A missing file or a dangling
+// symlink gives an error os.IsNotExist recognizes. Windows has no execute bit, so
+// there any regular file passes.
+func checkExecutable(path string) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	// Name the file a symlink points at, since that is the one to fix
+	via := ""
+	if target != path {
+		via = " (symlink to " + target + ")"
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file%s", via)
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return fmt.Errorf("not executable%s", via)
+	}
+	return nil
+}
 
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			// Reached root directory
-			break
+// expandPath replaces a leading "~" with the home directory and expands
+// $VAR and ${VAR}, as the shell would have. "~user" is left alone since
+// other users' home directories can't be looked up portably.
+func expandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
 		}
-		dir = parent
 	}
-	return ""
+	return os.ExpandEnv(path)
 }
 
-// getCurrentPhpVersion gets the version of PHP currently in PATH
-func getCurrentPhpVersion() string {
-	cmd := exec.Command("php", "--version")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
+// relativeTo joins a relative path onto baseDir, so a config kept with its
+// PHP binaries can be moved as a whole. Absolute paths, and any path when
+// baseDir is "", are returned unchanged.
+func relativeTo(baseDir, path string) string {
+	if baseDir == "" || filepath.IsAbs(path) {
+		return path
 	}
+	return filepath.Join(baseDir, path)
+}
 
-	// Parse PHP version from output
-	// Expected format: "PHP 8.2.0 (cli) ..." or "PHP 8.2.0-dev ..."
-	re := regexp.MustCompile(`PHP (\d+\.\d+)`)
-	matches := re.FindStringSubmatch(string(output))
-	if len(matches) >= 2 {
-		return matches[1]
+// applySetting stores entry in config if it is a setting rather than a
+// version, reporting whether it was one
+func applySetting(config *Config, entry configEntry) (bool, error) {
+	switch entry.Key {
+	case "file_mode":
+		mode, err := parseFileMode(entry.Value)
+		if err != nil {
+			return true, fmt.Errorf("invalid file_mode on line %d: %v", entry.Line, err)
+		}
+		config.FileMode = mode
+		return true, nil
+	case "default":
+		config.Default = entry.Value
+		return true, nil
+	case "checksum":
+		// Only read by --verify-checksum, before the entries are parsed
+		return true, nil
+	case "resolver":
+		config.Resolver = entry.Value
+		return true, nil
+	case "rule":
+		// "rule: <regexp> => <version>", matched against the directory path
+		arrow := strings.LastIndex(entry.Value, "=>")
+		if arrow < 0 {
+			return true, fmt.Errorf("invalid rule on line %d: expected \"<regexp> => <version>\"", entry.Line)
+		}
+		pattern, err := regexp.Compile(strings.TrimSpace(entry.Value[:arrow]))
+		if err != nil {
+			return true, fmt.Errorf("invalid rule pattern on line %d: %v", entry.Line, err)
+		}
+		config.Rules = append(config.Rules, versionRule{Pattern: pattern, Version: strings.TrimSpace(entry.Value[arrow+2:])})
+		return true, nil
+	case "sources":
+		order, err := parseSourceOrder(entry.Value)
+		if err != nil {
+			return true, fmt.Errorf("invalid sources on line %d: %v", entry.Line, err)
+		}
+		config.Sources = order
+		return true, nil
+	case "policy":
+		config.Policy = entry.Value
+		return true, nil
+	case "search_boundary":
+		if entry.Value != boundaryProject && entry.Value != boundaryNone {
+			return true, fmt.Errorf("invalid search_boundary on line %d: expected %s or %s", entry.Line, boundaryProject, boundaryNone)
+		}
+		config.Boundary = entry.Value
+		return true, nil
+	case "min_runner_version":
+		if _, ok := parseVersionParts(strings.TrimPrefix(entry.Value, "v")); !ok {
+			return true, fmt.Errorf("invalid min_runner_version on line %d: %s", entry.Line, entry.Value)
+		}
+		config.MinRunner = entry.Value
+		return true, nil
 	}
 
-	return ""
+	// Per-version settings are written "<setting>.<version>: value"
+	setting, scope, ok := strings.Cut(entry.Key, ".")
+	if !ok {
+		return false, nil
+	}
+	switch setting {
+	case "ini_scan_dir":
+		config.IniScanDirs[scope] = entry.Value
+	case "env":
+		if name, _, ok := strings.Cut(entry.Value, "="); !ok || name == "" {
+			return true, fmt.Errorf("invalid env on line %d: expected \"NAME=value\"", entry.Line)
+		}
+		config.Env[scope] = append(config.Env[scope], entry.Value)
+	case "args":
+		// "args.8.2: -d memory_limit=-1", split on whitespace
+		config.Args[scope] = append(config.Args[scope], strings.Fields(entry.Value)...)
+	case "alias":
+		config.Aliases[scope] = entry.Value
+	case "install_hint":
+		config.InstallHints[scope] = entry.Value
+	case "deprecated":
+		config.Deprecations[scope] = entry.Value
+	case "type_default":
+		config.TypeDefaults[scope] = entry.Value
+	case "eol":
+		if _, err := time.Parse(time.DateOnly, entry.Value); err != nil {
+			return true, fmt.Errorf("invalid eol date on line %d: expected YYYY-MM-DD", entry.Line)
+		}
+		config.EOLDates[scope] = entry.Value
+	case "exit_code":
+		code, err := parseExitCode(scope, entry.Value)
+		if err != nil {
+			return true, fmt.Errorf("invalid exit_code on line %d: %v", entry.Line, err)
+		}
+		config.ExitCodes[scope] = code
+	default:
+		return false, nil
+	}
+	return true, nil
 }
 
-// createPhpVersionFile creates a .php-version file with the specified version
-func createPhpVersionFile(dir, version string) {
-	versionPath := filepath.Join(dir, versionFile)
-	err := os.WriteFile(versionPath, []byte(version+"\n"), 0644)
-	if err != nil {
-		fmt.Printf("Warning: Could not create %s: %v\n", versionPath, err)
-	} else {
-		fmt.Printf("Created %s with PHP version %s\n", versionPath, version)
+// parseFileMode parses an octal permission such as "0664"
+func parseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%q is not an octal file mode such as 0644", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// checkRunnerVersion refuses a config that needs a newer php-runner than this
+// one. Development builds have no release number and are always accepted.
+func checkRunnerVersion(minimum string) error {
+	if minimum == "" {
+		return nil
+	}
+	current, ok := parseVersionParts(strings.TrimPrefix(runnerVersion, "v"))
+	if !ok {
+		return nil
+	}
+	required, _ := parseVersionParts(strings.TrimPrefix(minimum, "v"))
+	if compareVersionParts(current, required) < 0 {
+		return fmt.Errorf("this config requires php-runner %s or newer, but this is %s; please upgrade php-runner", minimum, runnerVersion)
 	}
+	return nil
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// runnerMainEnv makes the test binary run php-runner's main instead of the tests
+const runnerMainEnv = "PHP_RUNNER_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runnerMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// isolate resets php-runner's flags and state for a test and clears the
+// caller's PHP_RUNNER_* variables and config locations, restoring them after
+func isolate(t *testing.T) {
+	t.Helper()
+	savedOpts, savedDir := opts, configDir
+	opts = options{probeTimeout: defaultProbeTimeout, onMissing: onMissingFallback}
+	configDir = ""
+	t.Cleanup(func() { opts, configDir = savedOpts, savedDir })
+
+	for _, name := range runnerEnvVars {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+}
+
+// writeFile writes content to path, creating its directory
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeStub writes an executable shell script standing in for PHP or
+// another tool. Tests using stubs are skipped where there is no sh.
+func writeStub(t *testing.T, path, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub scripts need a POSIX shell")
+	}
+	writeFile(t, path, "#!/bin/sh\n"+script+"\n")
+	if err := os.Chmod(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// captureStderr runs f with os.Stderr going to a file and returns what was
+// written there
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	saved := os.Stderr
+	os.Stderr = file
+	defer func() { os.Stderr = saved }()
+
+	f()
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// runRunner runs php-runner in a child process in dir, with env added to the
+// test's environment, and returns its stdout, stderr and exit code
+func runRunner(t *testing.T, dir string, env []string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), runnerMainEnv+"=1"), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("running php-runner: %v", err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+func TestPhpCommandArgs(t *testing.T) {
+	isolate(t)
+	bin := t.TempDir()
+	docker := writeStub(t, filepath.Join(bin, "docker"), "exit 0")
+	t.Setenv("PATH", bin)
+
+	config := newConfig()
+	config.Versions["8.1"] = "/usr/bin/php8.1"
+	config.Versions["8.2"] = "/usr/bin/php8.2"
+	config.Versions["8.3"] = "docker://php:8.3-cli"
+	config.Args["8.2"] = []string{"-d", "memory_limit=-1"}
+	config.Args["8.3"] = []string{"-d", "memory_limit=-1"}
+
+	tests := []struct {
+		version string
+		path    string
+		tail    []string // the last arguments, after any runtime options
+	}{
+		{"8.1", "/usr/bin/php8.1", []string{"/usr/bin/php8.1", "x.php", "-v"}},
+		{"8.2", "/usr/bin/php8.2", []string{"/usr/bin/php8.2", "-d", "memory_limit=-1", "x.php", "-v"}},
+		{"8.3", docker, []string{"php:8.3-cli", "php", "-d", "memory_limit=-1", "x.php", "-v"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			cmd, err := phpCommand(config, tt.version, "/project", []string{"x.php", "-v"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Path != tt.path {
+				t.Errorf("path = %s, want %s", cmd.Path, tt.path)
+			}
+			if len(cmd.Args) < len(tt.tail) || !slices.Equal(cmd.Args[len(cmd.Args)-len(tt.tail):], tt.tail) {
+				t.Errorf("args = %q, want them to end with %q", cmd.Args, tt.tail)
+			}
+			if cmd.Dir != "/project" {
+				t.Errorf("dir = %s, want /project", cmd.Dir)
+			}
+		})
+	}
+}
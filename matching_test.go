@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatching(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		code int
+	}{
+		{name: "several", args: []string{"^8.1"}, want: []string{"8.1", "8.3"}},
+		{name: "constraint in several arguments", args: []string{">=7.4", "<8.1"}, want: []string{"7.4", "8.0"}},
+		{name: "one", args: []string{"~8.0.0"}, want: []string{"8.0"}},
+		{name: "none", args: []string{"^9.0"}, code: 1},
+		{name: "several as JSON", args: []string{"--json", "^8.1"}, want: []string{"8.1", "8.3"}},
+		{name: "none as JSON", args: []string{"--json", "^9.0"}, want: []string{}, code: 1},
+		{name: "invalid constraint", args: []string{"eight"}, code: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			// Listed out of order, to check the output is sorted
+			var config string
+			for _, version := range []string{"8.3", "7.4", "8.1", "8.0"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, append([]string{"matching"}, tt.args...)...)
+			if code != tt.code {
+				t.Fatalf("exited %d, want %d: %s", code, tt.code, stderr)
+			}
+			if tt.args[0] != "--json" {
+				if got := strings.Fields(stdout); !reflect.DeepEqual(got, tt.want) && len(got)+len(tt.want) > 0 {
+					t.Errorf("printed %q, want %q", got, tt.want)
+				}
+				return
+			}
+			var matches []struct {
+				Version string `json:"version"`
+				Path    string `json:"path"`
+			}
+			if err := json.Unmarshal([]byte(stdout), &matches); err != nil {
+				t.Fatalf("printed %q: %v", stdout, err)
+			}
+			got := []string{}
+			for _, match := range matches {
+				if want := filepath.Join(root, "php"+match.Version); match.Path != want {
+					t.Errorf("path of %s = %s, want %s", match.Version, match.Path, want)
+				}
+				got = append(got, match.Version)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
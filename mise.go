@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// miseFiles are the mise (formerly rtx) tool files checked in each directory,
+// in the order mise itself gives them precedence
+var miseFiles = []string{".mise.toml", "mise.toml", ".rtx.toml"}
+
+// findMiseVersion looks for a mise/rtx tool file in the current and parent
+// directories, up to the search boundary if bounded, and returns the php
+// version from its [tools] table
+func findMiseVersion(startDir string, bounded bool) (string, string) {
+	var version, toolPath string
+	walkUp(startDir, bounded, func(dir string) bool {
+		for _, name := range miseFiles {
+			toolPath = filepath.Join(dir, name)
+			if version = readMiseVersion(toolPath); version != "" {
+				return true
+			}
+		}
+		return false
+	})
+	if version == "" {
+		return "", ""
+	}
+	return version, toolPath
+}
+
+// readMiseVersion extracts the php entry from the [tools] table of a mise
+// TOML file. Only the forms mise documents for tool versions are understood:
+//
+//	php = "8.2"
+//	php = ["8.2", "8.1"]         (the first entry is the active one)
+//	php = { version = "8.2" }
+func readMiseVersion(toolPath string) string {
+	file, err := os.Open(toolPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inTools := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripTomlComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			inTools = line == "[tools]"
+			continue
+		}
+		if !inTools {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.Trim(strings.TrimSpace(key), `"'`) != "php" {
+			continue
+		}
+		return parseMiseToolValue(strings.TrimSpace(value))
+	}
+	return ""
+}
+
+// parseMiseToolValue returns the version from the right-hand side of a
+// [tools] entry
+func parseMiseToolValue(value string) string {
+	switch {
+	case strings.HasPrefix(value, "["):
+		value = strings.TrimPrefix(value, "[")
+		first, _, _ := strings.Cut(value, ",")
+		return tomlString(strings.TrimSuffix(strings.TrimSpace(first), "]"))
+	case strings.HasPrefix(value, "{"):
+		table := strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}")
+		for _, field := range strings.Split(table, ",") {
+			key, fieldValue, ok := strings.Cut(field, "=")
+			if ok && strings.TrimSpace(key) == "version" {
+				return tomlString(strings.TrimSpace(fieldValue))
+			}
+		}
+		return ""
+	}
+	return tomlString(value)
+}
+
+// tomlString unquotes a basic or literal TOML string
+func tomlString(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return strings.TrimSpace(value[1 : len(value)-1])
+	}
+	return ""
+}
+
+// stripTomlComment removes a trailing # comment that is not inside a string
+func stripTomlComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// readToolVersions returns the php entry of an asdf .tool-versions file,
+// where each line is a tool name followed by one or more versions
+func readToolVersions(toolPath string) string {
+	file, err := os.Open(toolPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "php" {
+			return fields[1]
+		}
+	}
+	return ""
+}
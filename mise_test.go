@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMiseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "string", content: "[tools]\nphp = \"8.2\"\n", want: "8.2"},
+		{name: "literal string", content: "[tools]\nphp = '8.1'\n", want: "8.1"},
+		{name: "list", content: "[tools]\nphp = [\"8.3\", \"8.2\"]\n", want: "8.3"},
+		{name: "table", content: "[tools]\nphp = { version = \"8.2\", postinstall = \"x\" }\n", want: "8.2"},
+		{name: "quoted key", content: "[tools]\n\"php\" = \"8.2\"\n", want: "8.2"},
+		{name: "comment", content: "[tools]\nphp = \"8.2\" # pinned for CI\n", want: "8.2"},
+		{name: "hash in string", content: "[tools]\nnode = \"#20\"\nphp = \"8.2\"\n", want: "8.2"},
+		{name: "other table", content: "[env]\nphp = \"8.2\"\n[tools]\nnode = \"20\"\n"},
+		{name: "no php", content: "[tools]\nnode = \"20\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), ".mise.toml"), tt.content)
+			if got := readMiseVersion(path); got != tt.want {
+				t.Errorf("readMiseVersion = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMiseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string // tool files by path relative to the project
+		want     string
+		wantFile string
+	}{
+		{name: "mise", files: map[string]string{".mise.toml": "8.2"}, want: "8.2", wantFile: ".mise.toml"},
+		{name: "rtx", files: map[string]string{".rtx.toml": "8.1"}, want: "8.1", wantFile: ".rtx.toml"},
+		{name: "mise before rtx", files: map[string]string{".mise.toml": "8.3", "mise.toml": "8.2", ".rtx.toml": "8.1"}, want: "8.3", wantFile: ".mise.toml"},
+		{name: "unhidden mise before rtx", files: map[string]string{"mise.toml": "8.2", ".rtx.toml": "8.1"}, want: "8.2", wantFile: "mise.toml"},
+		{name: "nearer directory first", files: map[string]string{".mise.toml": "8.3", "src/.rtx.toml": "8.1"}, want: "8.1", wantFile: "src/.rtx.toml"},
+		{name: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			project := t.TempDir()
+			for name, version := range tt.files {
+				writeFile(t, filepath.Join(project, name), "[tools]\nphp = \""+version+"\"\n")
+			}
+			start := filepath.Join(project, "src")
+			writeFile(t, filepath.Join(start, "index.php"), "")
+
+			version, path := findMiseVersion(start, true)
+			wantPath := ""
+			if tt.wantFile != "" {
+				wantPath = filepath.Join(project, tt.wantFile)
+			}
+			if version != tt.want || path != wantPath {
+				t.Errorf("findMiseVersion = %q, %q, want %q, %q", version, path, tt.want, wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// moduleCacheName is the file next to the config caching each binary's
+// "php -m" output, so commands comparing extensions don't rerun it
+const moduleCacheName = ".php-runner-modules"
+
+// moduleCacheEntry is the cached module list of one binary, valid while the
+// binary's stamp (modification time and size) is unchanged
+type moduleCacheEntry struct {
+	Stamp   string
+	Modules []string
+}
+
+// cachedModules returns the extensions of the binary at phpPath, running
+// "php -m" only if the cache has no list for the binary as it is now. A
+// rebuilt or upgraded binary has a new stamp and is probed again. Entries
+// are keyed by the resolved path, so a re-pointed "current" symlink doesn't
+// get the old target's list even if both binaries have the same stamp.
+func cachedModules(phpPath string) ([]string, error) {
+	statePath := stateFile(moduleCacheName)
+	target, err := filepath.EvalSymlinks(phpPath)
+	if err != nil {
+		return probeModules(phpPath)
+	}
+	info, err := os.Stat(target)
+	if statePath == "" || err != nil {
+		return probeModules(phpPath)
+	}
+	stamp := fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+
+	cache := readModuleCache(statePath)
+	if entry, ok := cache[target]; ok && entry.Stamp == stamp {
+		verbosef("modules of %s read from %s", phpPath, statePath)
+		countCacheLookup("modules", true)
+		return entry.Modules, nil
+	}
+	countCacheLookup("modules", false)
+
+	modules, err := probeModules(phpPath)
+	if err != nil {
+		return nil, err
+	}
+	cache[target] = moduleCacheEntry{Stamp: stamp, Modules: modules}
+	// Failing to save only means probing again next time
+	writeModuleCache(statePath, cache)
+	return modules, nil
+}
+
+// readModuleCache reads the cache file, one binary per line as
+// "<path>\t<stamp>\t<module>,<module>,...". A missing or damaged file is an
+// empty cache.
+func readModuleCache(statePath string) map[string]moduleCacheEntry {
+	cache := make(map[string]moduleCacheEntry)
+	content, err := os.ReadFile(statePath)
+	if err != nil {
+		return cache
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		var modules []string
+		if fields[2] != "" {
+			modules = strings.Split(fields[2], ",")
+		}
+		cache[fields[0]] = moduleCacheEntry{Stamp: fields[1], Modules: modules}
+	}
+	return cache
+}
+
+// writeModuleCache replaces the cache file with the given entries
+func writeModuleCache(statePath string, cache map[string]moduleCacheEntry) error {
+	var content strings.Builder
+	for path, entry := range cache {
+		fmt.Fprintf(&content, "%s\t%s\t%s\n", path, entry.Stamp, strings.Join(entry.Modules, ","))
+	}
+	return writeFileAtomic(statePath, []byte(content.String()), 0644)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachedModules(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	configDir = filepath.Join(root, "conf")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runs := filepath.Join(root, "runs")
+	// Each binary records that it was probed, and lists its own extension
+	stub := func(name, ext string) string {
+		return writeStub(t, filepath.Join(root, name), `echo run >> `+runs+`
+printf '[PHP Modules]\ncore\n`+ext+`\n'`)
+	}
+	php := stub("php8.2", "intl")
+	other := stub("php8.3", "sodium")
+	// The two binaries must be told apart by path alone
+	stamp := time.Unix(1700000000, 0)
+	for _, path := range []string{php, other} {
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current := filepath.Join(root, "current")
+	if err := os.Symlink(php, current); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name   string
+		change func()
+		query  string
+		want   []string
+		probed bool
+	}{
+		{name: "first query", query: php, want: []string{"core", "intl"}, probed: true},
+		{name: "second query", query: php, want: []string{"core", "intl"}},
+		{name: "through a symlink", query: current, want: []string{"core", "intl"}},
+		{
+			name:   "rebuilt binary",
+			change: func() { stub("php8.2", "intl-debug") },
+			query:  php,
+			want:   []string{"core", "intl-debug"},
+			probed: true,
+		},
+		{name: "after the rebuild", query: php, want: []string{"core", "intl-debug"}},
+		{
+			name: "touched binary",
+			change: func() {
+				later := stamp.Add(time.Hour)
+				os.Chtimes(php, later, later)
+			},
+			query:  php,
+			want:   []string{"core", "intl-debug"},
+			probed: true,
+		},
+		{
+			name: "re-pointed symlink",
+			change: func() {
+				os.Remove(current)
+				os.Symlink(other, current)
+			},
+			query:  current,
+			want:   []string{"core", "sodium"},
+			probed: true,
+		},
+		{name: "other binary cached", query: other, want: []string{"core", "sodium"}},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		before := countRuns(t, runs)
+		modules, err := cachedModules(step.query)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(modules, step.want) {
+			t.Errorf("%s: modules = %q, want %q", step.name, modules, step.want)
+		}
+		if probed := countRuns(t, runs) > before; probed != step.probed {
+			t.Errorf("%s: probed = %v, want %v", step.name, probed, step.probed)
+		}
+	}
+
+	stats, _ := readCacheStats(filepath.Join(configDir, cacheStatsName))
+	if stats["modules hits"] != 4 || stats["modules misses"] != 4 {
+		t.Errorf("cache stats = %v, want 4 hits and 4 misses", stats)
+	}
+}
+
+// countRuns returns how many times the stubs have been run
+func countRuns(t *testing.T, path string) int {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(content), "run\n")
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionResult is the outcome of running PHP under one configured version
+type versionResult struct {
+	Version  string
+	ExitCode int
+	Err      error // set if PHP could not be started at all
+}
+
+// testAllCommand runs the given PHP arguments under every configured version,
+// optionally several at a time, and fails if any version fails
+func testAllCommand(args []string) int {
+	flags := flag.NewFlagSet("test-all", flag.ContinueOnError)
+	parallel := flags.Int("parallel", 1, "number of versions to run at once")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *parallel < 1 {
+		fmt.Fprintln(os.Stderr, "--parallel must be at least 1")
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner test-all [--parallel N] [--] <php arguments>")
+		return 2
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+	cwd, err := workingDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	versions := configuredVersions(config)
+	results := runAllVersions(config, versions, cwd, flags.Args(), *parallel)
+
+	failed := 0
+	fmt.Println()
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("%-8s error: %v\n", result.Version, result.Err)
+		case result.ExitCode != 0:
+			failed++
+			fmt.Printf("%-8s failed (exit %d)\n", result.Version, result.ExitCode)
+		default:
+			fmt.Printf("%-8s ok\n", result.Version)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d versions failed\n", failed, len(results))
+		return 1
+	}
+	return 0
+}
+
+// runAllVersions runs args under each version with at most parallel running
+// at once. Output is streamed line by line prefixed with the version, and
+// the results are returned in the order of versions.
+func runAllVersions(config *Config, versions []string, cwd string, args []string, parallel int) []versionResult {
+	results := make([]versionResult, len(versions))
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallel)
+
+	for i, version := range versions {
+		// Take a slot before starting so versions begin in order
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			prefix := "[" + version + "] "
+			stdout := &prefixWriter{out: os.Stdout, prefix: prefix, mu: &outputMu}
+			stderr := &prefixWriter{out: os.Stderr, prefix: prefix, mu: &outputMu}
+			results[i] = runVersion(config, version, cwd, args, stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runVersion runs args under a single version, sending its output to the
+// given writers
+func runVersion(config *Config, version, cwd string, args []string, stdout, stderr io.Writer) versionResult {
+	result := versionResult{Version: version}
+	cmd, err := phpCommand(config, version, cwd, args)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.Err = err
+	}
+	return result
+}
+
+// crossCheckCommand runs the given PHP arguments under every configured
+// version and fails unless all of them print the same stdout and exit with
+// the same code. Versions are grouped by outcome, and each group that
+// diverges from the oldest version is shown with its first differing line.
+func crossCheckCommand(args []string) int {
+	flags := flag.NewFlagSet("cross-check", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: php-runner cross-check [--] <script> [<arguments>]")
+		return 2
+	}
+
+	config, err := loadRunnerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+	cwd, err := workingDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+
+	// Group the versions by what they printed and how they exited, keeping
+	// the groups in the order their first version ran
+	var groups []*outcomeGroup
+	var outputMu sync.Mutex
+	for _, version := range configuredVersions(config) {
+		var stdout bytes.Buffer
+		stderr := &prefixWriter{out: os.Stderr, prefix: "[" + version + "] ", mu: &outputMu}
+		result := runVersion(config, version, cwd, flags.Args(), &stdout, stderr)
+		stderr.Flush()
+		if result.Err != nil {
+			groups = append(groups, &outcomeGroup{Versions: []string{version}, Err: result.Err})
+			continue
+		}
+
+		i := slices.IndexFunc(groups, func(group *outcomeGroup) bool {
+			return group.Err == nil && group.ExitCode == result.ExitCode && bytes.Equal(group.Output, stdout.Bytes())
+		})
+		if i < 0 {
+			groups = append(groups, &outcomeGroup{Output: stdout.Bytes(), ExitCode: result.ExitCode})
+			i = len(groups) - 1
+		}
+		groups[i].Versions = append(groups[i].Versions, version)
+	}
+	if len(groups) == 0 {
+		fmt.Fprintln(os.Stderr, "No PHP versions are configured")
+		return 1
+	}
+
+	reference := groups[0]
+	if len(groups) == 1 && reference.Err == nil {
+		fmt.Printf("All %d versions printed the same output and exited with %d\n", len(reference.Versions), reference.ExitCode)
+		return 0
+	}
+	for _, group := range groups {
+		versions := strings.Join(group.Versions, ", ")
+		switch {
+		case group.Err != nil:
+			fmt.Printf("%s: error: %v\n", versions, group.Err)
+		case group == reference:
+			fmt.Printf("%s: exit %d (reference)\n", versions, group.ExitCode)
+		default:
+			fmt.Printf("%s: exit %d\n", versions, group.ExitCode)
+			if reference.Err == nil && !bytes.Equal(group.Output, reference.Output) {
+				line, want, got := firstDifference(reference.Output, group.Output)
+				fmt.Printf("  stdout differs from line %d:\n    %s: %q\n    %s: %q\n",
+					line, reference.Versions[0], want, group.Versions[0], got)
+			}
+		}
+	}
+	fmt.Printf("%d versions gave %d different outcomes\n", countVersions(groups), len(groups))
+	return 1
+}
+
+// outcomeGroup is a set of versions that ran a script with the same result
+type outcomeGroup struct {
+	Versions []string
+	Output   []byte
+	ExitCode int
+	Err      error // set if PHP could not be started at all
+}
+
+// countVersions returns how many versions the groups hold in total
+func countVersions(groups []*outcomeGroup) int {
+	count := 0
+	for _, group := range groups {
+		count += len(group.Versions)
+	}
+	return count
+}
+
+// firstDifference returns the 1-based number of the first line where a and
+// b differ, and that line from each; a missing line is returned as ""
+func firstDifference(a, b []byte) (int, string, string) {
+	aLines := strings.SplitAfter(string(a), "\n")
+	bLines := strings.SplitAfter(string(b), "\n")
+	for i := 0; ; i++ {
+		var aLine, bLine string
+		if i < len(aLines) {
+			aLine = aLines[i]
+		}
+		if i < len(bLines) {
+			bLine = bLines[i]
+		}
+		if aLine != bLine || (i >= len(aLines) && i >= len(bLines)) {
+			return i + 1, aLine, bLine
+		}
+	}
+}
+
+// benchmarkWarmups is how many untimed runs precede the timed ones, so the
+// binary and its extensions are in the page cache
+const benchmarkWarmups = 2
+
+// benchmarkResult is the startup timing of one version
+type benchmarkResult struct {
+	Version        string
+	Mean, Min, Max time.Duration
+	Err            error
+}
+
+// benchmarkVersions runs an empty script under every configured version runs
+// times after a warmup and prints the mean, fastest and slowest startup
+// times, fastest version first
+func benchmarkVersions(config *Config, cwd string, runs int) int {
+	var results []benchmarkResult
+	for _, version := range configuredVersions(config) {
+		fmt.Fprintf(os.Stderr, "Benchmarking %s...\n", version)
+		results = append(results, benchmarkVersion(config, version, cwd, runs))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].Err == nil) != (results[j].Err == nil) {
+			return results[i].Err == nil
+		}
+		return results[i].Mean < results[j].Mean
+	})
+
+	failed := 0
+	fmt.Printf("%-10s %12s %12s %12s\n", "VERSION", "MEAN", "MIN", "MAX")
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("%-10s error: %v\n", result.Version, result.Err)
+			continue
+		}
+		fmt.Printf("%-10s %12s %12s %12s\n", result.Version,
+			result.Mean.Round(time.Microsecond), result.Min.Round(time.Microsecond), result.Max.Round(time.Microsecond))
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// benchmarkVersion times runs of php -r "" under a single version
+func benchmarkVersion(config *Config, version, cwd string, runs int) benchmarkResult {
+	result := benchmarkResult{Version: version}
+	var total time.Duration
+	for i := 0; i < benchmarkWarmups+runs; i++ {
+		cmd, err := phpCommand(config, version, cwd, []string{"-r", ""})
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		started := time.Now()
+		if err := cmd.Run(); err != nil {
+			result.Err = err
+			return result
+		}
+		elapsed := time.Since(started)
+		if i < benchmarkWarmups {
+			continue
+		}
+
+		total += elapsed
+		if result.Min == 0 || elapsed < result.Min {
+			result.Min = elapsed
+		}
+		if elapsed > result.Max {
+			result.Max = elapsed
+		}
+	}
+	result.Mean = total / time.Duration(runs)
+	return result
+}
+
+// configuredVersions returns the configured version keys in version order
+func configuredVersions(config *Config) []string {
+	versions := make([]string, 0, len(config.Versions))
+	for version := range config.Versions {
+		versions = append(versions, version)
+	}
+	sortVersions(versions)
+	return versions
+}
+
+// prefixWriter writes each complete line to out with a prefix, holding a
+// shared lock so lines from concurrent versions don't interleave
+type prefixWriter struct {
+	out     io.Writer
+	prefix  string
+	mu      *sync.Mutex
+	pending []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.writeLine(w.pending[:i+1])
+		w.pending = w.pending[i+1:]
+	}
+}
+
+// Flush writes any final line that didn't end in a newline
+func (w *prefixWriter) Flush() {
+	if len(w.pending) > 0 {
+		w.writeLine(append(w.pending, '\n'))
+		w.pending = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTestAllParallel(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	// Later versions finish first, so with --parallel the results come back
+	// out of order; 8.2 fails
+	scripts := map[string]string{
+		"8.1": "sleep 0.3; echo ran 8.1",
+		"8.2": "sleep 0.2; echo ran 8.2; echo broke >&2; exit 3",
+		"8.3": "sleep 0.1; echo ran 8.3",
+		"8.4": "echo ran 8.4",
+	}
+	var config string
+	for version, script := range scripts {
+		config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), script) + "\n"
+	}
+	writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+	summary := "8.1      ok\n8.2      failed (exit 3)\n8.3      ok\n8.4      ok\n1 of 4 versions failed\n"
+
+	for _, parallel := range []string{"1", "2", "4", "10"} {
+		t.Run(parallel, func(t *testing.T) {
+			stdout, stderr, code := runRunner(t, root, nil, "test-all", "--parallel", parallel, "x.php")
+			if code != 1 {
+				t.Errorf("exit code = %d, want 1", code)
+			}
+			for version := range scripts {
+				if line := "[" + version + "] ran " + version + "\n"; !strings.Contains(stdout, line) {
+					t.Errorf("stdout = %q, want %q", stdout, line)
+				}
+			}
+			if !strings.HasSuffix(stdout, summary) {
+				t.Errorf("stdout = %q, want it to end with %q", stdout, summary)
+			}
+			if !strings.Contains(stderr, "[8.2] broke\n") {
+				t.Errorf("stderr = %q, want 8.2's prefixed output", stderr)
+			}
+		})
+	}
+}
+
+func TestBenchmarkVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		scripts  map[string]string // run after counting the run
+		wantRows []string          // versions in the table, fastest first
+		wantErr  string            // version whose row is an error
+		wantCode int
+	}{
+		{
+			name:     "all run",
+			scripts:  map[string]string{"8.1": "sleep 0.1", "8.2": "exit 0", "8.3": "exit 0"},
+			wantRows: []string{"", "", "8.1"},
+		},
+		{
+			name:     "one fails",
+			scripts:  map[string]string{"8.1": "exit 0", "8.2": "exit 1", "8.3": "exit 0"},
+			wantRows: []string{"", "", "8.2"},
+			wantErr:  "8.2",
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for version, script := range tt.scripts {
+				runs := filepath.Join(root, "runs"+version)
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "echo >> '"+runs+"'\n"+script) + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+
+			stdout, stderr, code := runRunner(t, root, nil, "--benchmark-versions=3")
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+			if len(lines) != len(tt.scripts)+1 || strings.Fields(lines[0])[0] != "VERSION" {
+				t.Fatalf("stdout = %q, want a header and a row per version", stdout)
+			}
+			seen := make(map[string]bool)
+			for i, line := range lines[1:] {
+				fields := strings.Fields(line)
+				version := fields[0]
+				seen[version] = true
+				if want := tt.wantRows[i]; want != "" && version != want {
+					t.Errorf("row %d is %s, want %s", i+1, version, want)
+				}
+				if version == tt.wantErr {
+					if fields[1] != "error:" {
+						t.Errorf("row %q, want an error", line)
+					}
+					continue
+				}
+				if len(fields) != 4 {
+					t.Errorf("row %q, want mean, min and max", line)
+					continue
+				}
+				for _, field := range fields[1:] {
+					if _, err := time.ParseDuration(field); err != nil {
+						t.Errorf("row %q: %v", line, err)
+					}
+				}
+				// Two warmup runs come before the timed ones
+				if runs, _ := os.ReadFile(filepath.Join(root, "runs"+version)); len(runs) != benchmarkWarmups+3 {
+					t.Errorf("%s ran %d times, want %d", version, len(runs), benchmarkWarmups+3)
+				}
+			}
+			for version := range tt.scripts {
+				if !seen[version] {
+					t.Errorf("stdout = %q, want a row for %s", stdout, version)
+				}
+			}
+		})
+	}
+}
+
+func TestFirstDifference(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		line  int
+		aLine string
+		bLine string
+	}{
+		{a: "one\ntwo\n", b: "one\nTWO\n", line: 2, aLine: "two\n", bLine: "TWO\n"},
+		{a: "one\n", b: "one\ntwo\n", line: 2, bLine: "two\n"},
+		{a: "one\ntwo", b: "one\ntwo\n", line: 2, aLine: "two", bLine: "two\n"},
+		{a: "", b: "one\n", line: 1, bLine: "one\n"},
+	}
+	for _, tt := range tests {
+		line, aLine, bLine := firstDifference([]byte(tt.a), []byte(tt.b))
+		if line != tt.line || aLine != tt.aLine || bLine != tt.bLine {
+			t.Errorf("firstDifference(%q, %q) = %d, %q, %q, want %d, %q, %q", tt.a, tt.b, line, aLine, bLine, tt.line, tt.aLine, tt.bLine)
+		}
+	}
+}
+
+func TestCrossCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts map[string]string // the stub for each version
+		want    []string          // lines the report must contain
+		code    int
+	}{
+		{
+			name:    "identical",
+			scripts: map[string]string{"8.1": "echo same; echo out", "8.2": "echo same; echo out", "8.3": "echo same; echo out"},
+			want:    []string{"All 3 versions printed the same output and exited with 0"},
+		},
+		{
+			name:    "identical failures",
+			scripts: map[string]string{"8.1": "echo same; exit 3", "8.2": "echo same; exit 3"},
+			want:    []string{"All 2 versions printed the same output and exited with 3"},
+		},
+		{
+			name:    "different output",
+			scripts: map[string]string{"8.1": "echo same; echo old", "8.2": "echo same; echo old", "8.3": "echo same; echo new; echo extra"},
+			want: []string{
+				"8.1, 8.2: exit 0 (reference)",
+				"8.3: exit 0",
+				"  stdout differs from line 2:",
+				`    8.1: "old\n"`,
+				`    8.3: "new\n"`,
+				"3 versions gave 2 different outcomes",
+			},
+			code: 1,
+		},
+		{
+			name:    "different exit code",
+			scripts: map[string]string{"8.1": "echo same", "8.2": "echo same; exit 2"},
+			want:    []string{"8.1: exit 0 (reference)", "8.2: exit 2", "2 versions gave 2 different outcomes"},
+			code:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for version, script := range tt.scripts {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), script) + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, "cross-check", "x.php")
+			if code != tt.code {
+				t.Errorf("exit code = %d, want %d: %s%s", code, tt.code, stdout, stderr)
+			}
+			lines := strings.Split(stdout, "\n")
+			for _, want := range tt.want {
+				if !slices.Contains(lines, want) {
+					t.Errorf("report %q has no line %q", stdout, want)
+				}
+			}
+			if tt.code == 0 && strings.Contains(stdout, "differs") {
+				t.Errorf("report = %q, want no differences", stdout)
+			}
+		})
+	}
+}
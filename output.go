@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// writeGithubOutput appends the resolved version and path to the file named by
+// $GITHUB_OUTPUT so later GitHub Actions steps can read them as step outputs.
+// It does nothing outside of GitHub Actions.
+func writeGithubOutput(version, phpPath string) error {
+	outputPath := os.Getenv("GITHUB_OUTPUT")
+	if outputPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %v", outputPath, err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "php-version=%s\nphp-path=%s\n", version, phpPath); err != nil {
+		return fmt.Errorf("cannot write %s: %v", outputPath, err)
+	}
+	return nil
+}
+
+// writeEnvFile writes the resolution to a dotenv file at path for
+// --print-resolved-env-file, replacing any previous contents. Values are
+// quoted so the file can also be sourced by a POSIX shell.
+func writeEnvFile(path string, resolution Resolution, phpPath string) error {
+	content := fmt.Sprintf("PHP_VERSION=%s\nPHP_BINARY=%s\nPHP_VERSION_SOURCE=%s\n",
+		shellQuote(resolution.Version), shellQuote(phpPath), shellQuote(resolution.Source))
+	return writeFileAtomic(path, []byte(content), 0644)
+}
+
+// shellQuote quotes s for POSIX shells, leaving it bare when it only
+// contains characters that are never special
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// openOutputFile opens path to receive PHP's output, truncating it unless
+// --append was given
+func openOutputFile(path string) (*os.File, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if opts.appendOutput {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open output file: %v", err)
+	}
+	return file, nil
+}
+
+// maxTailBytes bounds how much of PHP's stderr a tailWriter holds on to
+const maxTailBytes = 64 * 1024
+
+// tailWriter remembers the last lines written to it
+type tailWriter struct {
+	lines int
+	buf   []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > maxTailBytes {
+		w.buf = w.buf[len(w.buf)-maxTailBytes:]
+	}
+	return len(p), nil
+}
+
+// Tail returns up to the last w.lines lines written
+func (w *tailWriter) Tail() []string {
+	text := strings.TrimRight(string(w.buf), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > w.lines {
+		lines = lines[len(lines)-w.lines:]
+	}
+	return lines
+}
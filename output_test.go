@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGithubOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // contents of $GITHUB_OUTPUT before the run, or "-" for no file
+		path     string // $GITHUB_OUTPUT relative to the temp dir, "" to leave it unset
+		want     string
+		wantCode int
+	}{
+		{name: "new file", existing: "-", path: "output", want: "php-version=8.2\nphp-path=%s\n"},
+		{name: "appended", existing: "earlier=1\n", path: "output", want: "earlier=1\nphp-version=8.2\nphp-path=%s\n"},
+		{name: "unset", existing: "-"},
+		{name: "unwritable", existing: "-", path: filepath.Join("missing", "output"), wantCode: exitCantCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+			configPath := writeFile(t, filepath.Join(root, configFileName), "8.2: "+php+"\n")
+			env := []string{"PHP_RUNNER_CONFIG=" + configPath, "GITHUB_OUTPUT="}
+			outputPath := filepath.Join(root, "output")
+			if tt.path != "" {
+				env[1] = "GITHUB_OUTPUT=" + filepath.Join(root, tt.path)
+			}
+			if tt.existing != "-" {
+				writeFile(t, outputPath, tt.existing)
+			}
+
+			_, stderr, code := runRunner(t, root, env, "--github-output")
+			if code != tt.wantCode {
+				t.Fatalf("exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			content, err := os.ReadFile(outputPath)
+			if tt.want == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("%s was written: %q", outputPath, content)
+				}
+				return
+			}
+			if want := fmt.Sprintf(tt.want, php); string(content) != want {
+				t.Errorf("GITHUB_OUTPUT = %q, want %q", content, want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/usr/bin/php8.2", "/usr/bin/php8.2"},
+		{"/opt/My PHP/bin/php", "'/opt/My PHP/bin/php'"},
+		{"/opt/it's/php", `'/opt/it'\''s/php'`},
+		{"/opt/$HOME/php", "'/opt/$HOME/php'"},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWhichShellEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string // directory holding the PHP binary, under the temp dir
+	}{
+		{name: "plain", dir: "php"},
+		{name: "spaces", dir: "My PHP Builds"},
+		{name: "quote", dir: "it's here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, tt.dir, "php"), "exit 0")
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "8.2: "+php+"\n")
+
+			stdout, stderr, code := runRunner(t, root, nil, "which", "--shell-escape")
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			// The shell must read the output back as the one original path
+			quoted := strings.TrimSuffix(stdout, "\n")
+			echoed, err := exec.Command("sh", "-c", "printf '%s' "+quoted).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(echoed) != php {
+				t.Errorf("the shell read %s as %q, want %q", quoted, echoed, php)
+			}
+		})
+	}
+}
+
+func TestOutputFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		append     bool
+		existing   string // earlier contents of both files
+		dir        string // directory of both files under the temp dir, if not the temp dir itself
+		wantStdout string
+		wantStderr string
+		wantCode   int
+	}{
+		{name: "new files", wantStdout: "OUT_MARK\n", wantStderr: "ERR_MARK\n"},
+		{name: "truncated", existing: "old\n", wantStdout: "OUT_MARK\n", wantStderr: "ERR_MARK\n"},
+		{name: "appended", append: true, existing: "old\n", wantStdout: "old\nOUT_MARK\n", wantStderr: "old\nERR_MARK\n"},
+		{name: "missing directory", dir: "missing", wantCode: exitCantCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "echo OUT_MARK; echo ERR_MARK >&2")
+			configPath := writeFile(t, filepath.Join(root, configFileName), "8.2: "+php+"\n")
+			outPath, errPath := filepath.Join(root, tt.dir, "out.log"), filepath.Join(root, tt.dir, "err.log")
+			if tt.existing != "" {
+				writeFile(t, outPath, tt.existing)
+				writeFile(t, errPath, tt.existing)
+			}
+
+			args := []string{"--stdout", outPath, "--stderr", errPath, "x.php"}
+			if tt.append {
+				args = append([]string{"--append"}, args...)
+			}
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, args...)
+			if code != tt.wantCode {
+				t.Fatalf("exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if strings.Contains(stdout+stderr, "_MARK") {
+				t.Errorf("PHP's output reached php-runner's streams: %q, %q", stdout, stderr)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+			for path, want := range map[string]string{outPath: tt.wantStdout, errPath: tt.wantStderr} {
+				if content, err := os.ReadFile(path); err != nil || string(content) != want {
+					t.Errorf("%s = %q, %v, want %q", filepath.Base(path), content, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStdinFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string // contents of the file in the working directory, if any
+		path     string // --stdin-file value
+		want     string
+		wantCode int
+	}{
+		{name: "relative path", input: "line 1\nline 2\n", path: "input.txt", want: "line 1\nline 2\n"},
+		{name: "no trailing newline", input: "last", path: "input.txt", want: "last"},
+		{name: "empty", input: "", path: "input.txt", want: ""},
+		{name: "missing", path: "missing.txt", wantCode: exitCantCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "exec cat")
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+php+"\n")
+			if tt.wantCode == 0 {
+				writeFile(t, filepath.Join(root, "input.txt"), tt.input)
+			}
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, "--stdin-file", tt.path, "x.php")
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d: %s", code, tt.wantCode, stdout+stderr)
+			}
+			if tt.wantCode == 0 && stdout != tt.want {
+				t.Errorf("PHP read %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		dir        string // directory holding the PHP binary, under the temp dir
+		wantBinary string // PHP_BINARY's value as written, with {root} for the temp dir
+		envDir     string // directory of the env file under the temp dir, if not the temp dir itself
+		wantCode   int
+	}{
+		{name: "plain", dir: "php", wantBinary: "{root}/php/php"},
+		{name: "spaces", dir: "My PHP Builds", wantBinary: "'{root}/My PHP Builds/php'"},
+		{name: "quote", dir: "it's here", wantBinary: `'{root}/it'\''s here/php'`},
+		{name: "shell syntax", dir: "$(id) `id` ;", wantBinary: "'{root}/$(id) `id` ;/php'"},
+		{name: "missing directory", dir: "php", envDir: "missing", wantCode: exitCantCreate},
+	}
+	for _, tt := range tests {
+		for _, args := range [][]string{nil, {"x.php"}} {
+			t.Run(fmt.Sprintf("%s/%d args", tt.name, len(args)), func(t *testing.T) {
+				isolate(t)
+				root := t.TempDir()
+				php := writeStub(t, filepath.Join(root, tt.dir, "php"), "echo ran")
+				configPath := writeFile(t, filepath.Join(root, configFileName), "8.2: "+php+"\n8.3: "+php+"\n")
+				writeFile(t, filepath.Join(root, versionFile), "8.2\n")
+				envFile := filepath.Join(root, tt.envDir, "php.env")
+				if tt.wantCode == 0 {
+					// An earlier run's file is replaced, not appended to
+					writeFile(t, envFile, "PHP_VERSION=8.3\n")
+				}
+
+				cmdArgs := append([]string{"--print-resolved-env-file", envFile}, args...)
+				stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, cmdArgs...)
+				if code != tt.wantCode {
+					t.Fatalf("exit code %d, want %d: %s", code, tt.wantCode, stdout+stderr)
+				}
+				if tt.wantCode != 0 {
+					if !strings.Contains(stdout+stderr, "Error writing "+envFile) || strings.Contains(stdout, "ran") {
+						t.Errorf("output = %q, want an error naming %s and PHP not run", stdout+stderr, envFile)
+					}
+					return
+				}
+				// Only recording the resolution doesn't run PHP
+				if ran := stdout == "ran\n"; ran != (len(args) > 0) {
+					t.Errorf("stdout = %q with arguments %q", stdout, args)
+				}
+
+				content, err := os.ReadFile(envFile)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := "PHP_VERSION=8.2\nPHP_BINARY=" + strings.ReplaceAll(tt.wantBinary, "{root}", root) + "\nPHP_VERSION_SOURCE=php-version\n"
+				if string(content) != want {
+					t.Errorf("%s = %q, want %q", envFile, content, want)
+				}
+				// Sourced by a shell, the values come back unchanged
+				sourced, err := exec.Command("sh", "-c", `. "$0" && printf '%s|%s|%s' "$PHP_VERSION" "$PHP_BINARY" "$PHP_VERSION_SOURCE"`, envFile).Output()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := "8.2|" + php + "|php-version"; string(sourced) != want {
+					t.Errorf("sourcing %s gave %q, want %q", envFile, sourced, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// packageOwner returns the system package that installed the executable at
+// path, asking dpkg on Linux and reading Homebrew's Cellar layout on macOS,
+// or "" if it can't tell
+func packageOwner(path string) string {
+	if _, _, isContainer := parseContainerPath(path); isContainer {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "linux":
+		if owner := dpkgOwner(path); owner != "" {
+			return owner
+		}
+		// /usr/bin/php is usually a link through /etc/alternatives
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved != path {
+			return dpkgOwner(resolved)
+		}
+	case "darwin":
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return parseCellarPath(resolved)
+		}
+	}
+	return ""
+}
+
+// dpkgOwner asks dpkg which package installed path
+func dpkgOwner(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "dpkg", "-S", path)
+	cmd.WaitDelay = 100 * time.Millisecond
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return parseDpkgOutput(string(output))
+}
+
+// parseDpkgOutput extracts the package from "dpkg -S" output such as
+// "php8.2-cli: /usr/bin/php8.2"
+func parseDpkgOutput(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		// Diversions are reported on lines of their own
+		if strings.HasPrefix(line, "diversion by") {
+			continue
+		}
+		if pkg, _, ok := strings.Cut(line, ": "); ok {
+			return pkg
+		}
+	}
+	return ""
+}
+
+// parseCellarPath extracts the Homebrew formula and version from a path in
+// its Cellar, e.g. "php@8.2 8.2.10" from
+// /opt/homebrew/Cellar/php@8.2/8.2.10/bin/php
+func parseCellarPath(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts {
+		if part == "Cellar" && i+2 < len(parts) {
+			return parts[i+1] + " " + parts[i+2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseDpkgOutput(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"php8.2-cli: /usr/bin/php8.2\n", "php8.2-cli"},
+		{"diversion by php-wrapper from: /usr/bin/php8.2\ndiversion by php-wrapper to: /usr/bin/php8.2.real\nphp8.2-cli: /usr/bin/php8.2\n", "php8.2-cli"},
+		{"php8.3-cli:amd64: /usr/bin/php8.3\n", "php8.3-cli:amd64"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseDpkgOutput(tt.output); got != tt.want {
+			t.Errorf("parseDpkgOutput(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestParseCellarPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/opt/homebrew/Cellar/php@8.2/8.2.10/bin/php", "php@8.2 8.2.10"},
+		{"/usr/local/Cellar/php/8.3.1/bin/php", "php 8.3.1"},
+		{"/usr/local/Cellar/php", ""},
+		{"/usr/bin/php8.2", ""},
+	}
+	for _, tt := range tests {
+		if got := parseCellarPath(tt.path); got != tt.want {
+			t.Errorf("parseCellarPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestListPackages(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("packages are looked up with dpkg only on Linux")
+	}
+	isolate(t)
+	root := t.TempDir()
+	bin := filepath.Join(root, "bin")
+	// Owns the php8.2 and php8.3 binaries by name, and nothing else
+	writeStub(t, filepath.Join(bin, "dpkg"), `case "$2" in
+*/php8.2|*/php8.3) echo "diversion by nobody to: $2.real"; echo "$(basename "$2")-cli: $2" ;;
+*) echo "dpkg-query: no path found matching pattern $2" >&2; exit 1 ;;
+esac`)
+	php82 := writeStub(t, filepath.Join(root, "usr", "bin", "php8.2"), "exit 0")
+	php83 := writeStub(t, filepath.Join(root, "usr", "bin", "php8.3"), "exit 0")
+	// Unowned itself, but a link to a binary that is
+	linked := filepath.Join(root, "alternatives", "php")
+	if err := os.MkdirAll(filepath.Dir(linked), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(php83, linked); err != nil {
+		t.Fatal(err)
+	}
+	custom := writeStub(t, filepath.Join(root, "opt", "php"), "exit 0")
+	configPath := writeFile(t, filepath.Join(root, configFileName),
+		"8.1: "+custom+"\n8.2: "+php82+"\n8.3: "+linked+"\n")
+	env := []string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"), "PHP_RUNNER_CONFIG=" + configPath}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"list", "--packages"},
+			want: []string{
+				"8.1      " + custom,
+				"8.2      " + php82 + " [php8.2-cli]",
+				"8.3      " + linked + " [php8.3-cli]",
+			},
+		},
+		{
+			args: []string{"list"},
+			want: []string{
+				"8.1      " + custom,
+				"8.2      " + php82,
+				"8.3      " + linked,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			stdout, stderr, code := runRunner(t, root, env, tt.args...)
+			if code != 0 {
+				t.Fatalf("exited %d: %s", code, stderr)
+			}
+			var got []string
+			for _, line := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+				// Drop the selection marker and note
+				line = strings.TrimLeft(line, "* ")
+				line, _, _ = strings.Cut(line, " (selected by")
+				got = append(got, line)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("list printed\n%s\nwant\n%s", stdout, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
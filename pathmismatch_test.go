@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWarnPathMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pin     string
+		pathPHP bool // whether there is a php on PATH, reporting 8.1.30
+		flag    bool
+		warning string
+	}{
+		{name: "matching", pin: "8.1", pathPHP: true, flag: true},
+		// Only the major and minor versions of the php on PATH are compared
+		{name: "same patch", pin: "8.1.30", pathPHP: true, flag: true},
+		{name: "other patch", pin: "8.1.27", pathPHP: true, flag: true},
+		{name: "mismatching", pin: "8.2", pathPHP: true, flag: true, warning: "the php on PATH is 8.1, but this project uses 8.2"},
+		{name: "no php on PATH", pin: "8.2", flag: true},
+		{name: "without the flag", pin: "8.2", pathPHP: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			bin := filepath.Join(root, "bin")
+			if err := os.MkdirAll(bin, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.pathPHP {
+				writeStub(t, filepath.Join(bin, "php"), `echo "PHP 8.1.30 (cli) (built: Jan  1 2024 00:00:00) (NTS)"`)
+			}
+			config := ""
+			for _, version := range []string{"8.1", "8.1.27", "8.1.30", "8.2"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			project := filepath.Join(root, "project")
+			writeFile(t, filepath.Join(project, versionFile), tt.pin+"\n")
+
+			var args []string
+			if tt.flag {
+				args = append(args, "--warn-path-mismatch")
+			}
+			_, stderr, code := runRunner(t, project, []string{"PATH=" + bin, "PHP_RUNNER_CONFIG=" + configPath}, append(args, "x.php")...)
+			if code != 0 {
+				t.Fatalf("exited %d: %s", code, stderr)
+			}
+			if tt.warning == "" {
+				if strings.Contains(stderr, "the php on PATH") {
+					t.Errorf("stderr = %q, want no mismatch warning", stderr)
+				}
+			} else if !strings.Contains(stderr, tt.warning) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPinFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permissions")
+	}
+	tests := []struct {
+		name     string
+		fileMode string // file_mode setting, if any
+		env      string // PHP_RUNNER_FILE_MODE, if any
+		want     os.FileMode
+		wantWarn bool
+	}{
+		{name: "default", want: 0644},
+		{name: "config", fileMode: "0664", want: 0664},
+		{name: "environment outranks config", fileMode: "0664", env: "0600", want: 0600},
+		{name: "invalid environment", fileMode: "0664", env: "rw-rw-r--", want: 0664, wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n"
+			if tt.fileMode != "" {
+				config += "file_mode: " + tt.fileMode + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, "project")
+			if err := os.MkdirAll(project, 0755); err != nil {
+				t.Fatal(err)
+			}
+			var env []string
+			if tt.env != "" {
+				env = append(env, "PHP_RUNNER_FILE_MODE="+tt.env)
+			}
+
+			_, stderr, code := runRunner(t, project, env, "--pin", "x.php")
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			info, err := os.Stat(filepath.Join(project, versionFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Errorf("mode = %#o, want %#o", got, tt.want)
+			}
+			if warned := strings.Contains(stderr, "ignoring PHP_RUNNER_FILE_MODE"); warned != tt.wantWarn {
+				t.Errorf("stderr = %q, want warning %v", stderr, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestPinReadOnlyDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permissions")
+	}
+	tests := []struct {
+		name     string
+		mode     os.FileMode // permissions of the project directory
+		wantFile bool
+	}{
+		{name: "writable", mode: 0755, wantFile: true},
+		{name: "read-only", mode: 0555},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "8.2: "+php+"\n")
+			project := filepath.Join(root, "project")
+			if err := os.Mkdir(project, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(project, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.Chmod(project, 0755) })
+			if dirWritable(project) != (tt.mode&0200 != 0) {
+				t.Skip("permissions don't restrict this user")
+			}
+
+			stdout, stderr, code := runRunner(t, project, nil, "--pin", "x.php")
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			_, err := os.Stat(filepath.Join(project, versionFile))
+			if created := err == nil; created != tt.wantFile {
+				t.Errorf("%s created = %v, want %v", versionFile, created, tt.wantFile)
+			}
+			if strings.Contains(stdout+stderr, "Could not create") {
+				t.Errorf("output = %q, want no failed write", stdout+stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPinAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		version string
+		want    map[string]string // the pins under the tree afterwards
+	}{
+		{
+			name:    "given version",
+			version: "8.2",
+			want:    map[string]string{"api": "8.2", "tool": "8.2", "lib": "8.1"},
+		},
+		{
+			name: "best match",
+			want: map[string]string{"api": "8.3", "lib": "8.1"},
+		},
+		{
+			name:    "force",
+			flags:   []string{"--force"},
+			version: "8.2",
+			want:    map[string]string{"api": "8.2", "tool": "8.2", "lib": "8.2"},
+		},
+		{
+			name:    "dry run",
+			flags:   []string{"--dry-run"},
+			version: "8.2",
+			want:    map[string]string{"lib": "8.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n" +
+				"8.3: " + writeStub(t, filepath.Join(root, "php8.3"), "exit 0") + "\n"
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+
+			tree := filepath.Join(root, "tree")
+			writeFile(t, filepath.Join(tree, "api", "composer.json"), `{"require": {"php": "^8.2"}}`)
+			writeFile(t, filepath.Join(tree, "tool", "composer.json"), `{"require": {}}`)
+			writeFile(t, filepath.Join(tree, "lib", "composer.json"), `{"require": {"php": ">=8.0"}}`)
+			writeFile(t, filepath.Join(tree, "lib", versionFile), "8.1\n")
+			// None of these is a subproject root
+			writeFile(t, filepath.Join(tree, "api", "vendor", "dep", "composer.json"), `{"require": {"php": "^8.0"}}`)
+			writeFile(t, filepath.Join(tree, "api", "src", "Kernel.php"), "<?php\n")
+			if err := os.MkdirAll(filepath.Join(tree, "docs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			args := append(append([]string{"pin-all"}, tt.flags...), tree)
+			if tt.version != "" {
+				args = append(args, tt.version)
+			}
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, args...)
+			if code != 0 {
+				t.Fatalf("pin-all exited %d: %s%s", code, stdout, stderr)
+			}
+
+			got := map[string]string{}
+			err := filepath.WalkDir(tree, func(path string, entry os.DirEntry, err error) error {
+				if err == nil && entry.Name() == versionFile {
+					rel, _ := filepath.Rel(tree, filepath.Dir(path))
+					got[filepath.ToSlash(rel)] = readVersionFile(path)
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pins = %v, want %v\n%s", got, tt.want, stdout)
+			}
+			if tt.name == "dry run" && !strings.Contains(stdout, "would pin "+filepath.Join(tree, "api")+" to 8.2") {
+				t.Errorf("stdout = %q, want a preview of each pin", stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestBrokenPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no SIGPIPE")
+	}
+	tests := []struct {
+		name   string
+		stream string // the stream piped into a reader that closes early
+		config string
+		want   int
+	}{
+		{name: "stdout", stream: "stdout", want: 128 + 13},
+		{name: "stderr", stream: "stderr", want: 128 + 13},
+		{name: "signal code overridden", stream: "stderr", config: "exit_code.signal: 3\n", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			// One line, then plenty more once the reader has gone
+			php := writeStub(t, filepath.Join(root, "php8.2"), `echo first >&`+map[string]string{"stdout": "1", "stderr": "2"}[tt.stream]+`
+sleep 0.2
+i=0
+while [ $i -lt 1000 ]; do echo more; echo more >&2; i=$((i+1)); done`)
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "8.2: "+php+"\n"+tt.config)
+
+			// Keep the end of stderr so php-runner copies it rather than
+			// handing it to PHP
+			cmd := exec.Command(os.Args[0], "--stderr-tail", "5", "x.php")
+			cmd.Dir = root
+			cmd.Env = append(os.Environ(), runnerMainEnv+"=1", "PHP_RUNNER_CONFIG="+configPath)
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var other bytes.Buffer
+			if tt.stream == "stdout" {
+				cmd.Stdout, cmd.Stderr = writer, &other
+			} else {
+				cmd.Stdout, cmd.Stderr = &other, writer
+			}
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+			writer.Close()
+			// Like "| head -1"
+			if _, err := bufio.NewReader(reader).ReadString('\n'); err != nil {
+				t.Fatal(err)
+			}
+			reader.Close()
+
+			err = cmd.Wait()
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				t.Fatal(err)
+			}
+			if code := cmd.ProcessState.ExitCode(); code != tt.want {
+				t.Errorf("exit code = %d, want %d", code, tt.want)
+			}
+			if bytes.Contains(other.Bytes(), []byte("Error executing")) {
+				t.Errorf("other stream = %q, want no exec error", other.String())
+			}
+		})
+	}
+}
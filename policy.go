@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// policyRule limits the versions a type of project may use
+type policyRule struct {
+	Text       string // the constraint as written, for messages
+	Constraint versionConstraint
+}
+
+// policyPath returns the policy file to enforce, if any. PHP_RUNNER_POLICY
+// overrides the config's policy setting.
+func policyPath(config *Config) string {
+	if path := os.Getenv("PHP_RUNNER_POLICY"); path != "" {
+		return path
+	}
+	return config.Policy
+}
+
+// loadPolicy reads a policy file mapping Composer project types to the
+// version constraints they must satisfy, one "<type>: <constraint>" per line
+func loadPolicy(path string) (map[string]policyRule, error) {
+	entries, err := readConfigEntries(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := make(map[string]policyRule)
+	for _, entry := range entries {
+		constraint, err := parseConstraint(entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", entry.Line, err)
+		}
+		policy[entry.Key] = policyRule{Text: entry.Value, Constraint: constraint}
+	}
+	return policy, nil
+}
+
+// applyPolicy checks a resolution against the policy for the project's type,
+// declared by the "type" in its composer.json. A version chosen explicitly by
+// the project must comply; a fallback is replaced by the highest configured
+// version the policy allows. Projects whose type the policy doesn't mention
+// are unconstrained.
+func applyPolicy(searchDir string, config *Config, resolution Resolution) (Resolution, error) {
+	path := policyPath(config)
+	if path == "" || opts.noFileSearch {
+		return resolution, nil
+	}
+	policy, err := loadPolicy(path)
+	if err != nil {
+		return Resolution{}, fmt.Errorf("loading policy from %s: %v", path, err)
+	}
+
+	manifestPath, manifest := findComposerManifest(searchDir, searchBounded(config))
+	if manifest == nil {
+		return resolution, nil
+	}
+	rule, ok := policy[manifest.Type]
+	if !ok || rule.Constraint.Allows(resolution.Version) {
+		return resolution, nil
+	}
+
+	if isFallbackSource(resolution.Source) {
+		if allowed := matchingVersions(config, rule.Constraint); len(allowed) > 0 {
+			version := allowed[len(allowed)-1]
+			return Resolution{Version: version, Path: config.Versions[version], Source: sourcePolicy}, nil
+		}
+		return Resolution{}, fmt.Errorf("policy %s allows %s for projects of type %q (%s), but none of the configured versions (%s) satisfy it",
+			path, rule.Text, manifest.Type, manifestPath, strings.Join(configuredVersions(config), ", "))
+	}
+	return Resolution{}, fmt.Errorf("PHP %s selected by %s violates policy %s: projects of type %q (%s) must use %s",
+		resolution.Version, resolution.Source, path, manifest.Type, manifestPath, rule.Text)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		envOnly bool   // name the policy in PHP_RUNNER_POLICY instead of the config
+		kind    string // type in composer.json, if any
+		pin     string // .php-version in the project, if any
+		want    string
+		wantErr string
+	}{
+		{name: "compliant pin", policy: "project: ^8.2", kind: "project", pin: "8.2", want: "8.2"},
+		{name: "violating pin", policy: "project: ^8.2", kind: "project", pin: "8.1", wantErr: `PHP 8.1 selected by php-version violates policy`},
+		{name: "fallback replaced", policy: "project: ~8.2.0", kind: "project", want: "8.2"},
+		{name: "nothing allowed", policy: "project: ^9.0", kind: "project", wantErr: `allows ^9.0 for projects of type "project"`},
+		{name: "untyped is a library", policy: "library: ^8.2", pin: "8.1", wantErr: `projects of type "library"`},
+		{name: "unlisted type", policy: "library: ^8.2", kind: "project", pin: "8.1", want: "8.1"},
+		{name: "from the environment", policy: "project: ^8.2", envOnly: true, kind: "project", pin: "8.1", wantErr: "violates policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			policyPath := writeFile(t, filepath.Join(root, "php-policy.yaml"), tt.policy+"\n")
+			config := "default: 8.1\n"
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			env := []string{}
+			if tt.envOnly {
+				env = append(env, "PHP_RUNNER_POLICY="+policyPath)
+			} else {
+				config += "policy: " + policyPath + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			env = append(env, "PHP_RUNNER_CONFIG="+configPath)
+			project := filepath.Join(root, "project")
+			manifest := "{}"
+			if tt.kind != "" {
+				manifest = `{"type": "` + tt.kind + `"}`
+			}
+			writeFile(t, filepath.Join(project, "composer.json"), manifest)
+			if tt.pin != "" {
+				writeFile(t, filepath.Join(project, versionFile), tt.pin+"\n")
+			}
+
+			stdout, stderr, code := runRunner(t, project, env, "current")
+			if tt.wantErr != "" {
+				if code == 0 || !strings.Contains(stderr, tt.wantErr) {
+					t.Errorf("current exited %d with %q, want an error containing %q", code, stderr, tt.wantErr)
+				}
+				return
+			}
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
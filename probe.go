@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// versionKeyRe matches the numeric part of a config key, e.g. "8.2" in "8.2-zts"
+var versionKeyRe = regexp.MustCompile(`^\d+(\.\d+)*`)
+
+// probeBinaryVersion runs phpPath to ask for its real PHP_VERSION
+func probeBinaryVersion(phpPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.probeTimeout)
+	defer cancel()
+
+	done := probeProgress(phpPath)
+	cmd := exec.CommandContext(ctx, phpPath, "-r", "echo PHP_VERSION;")
+	cmd.WaitDelay = 100 * time.Millisecond
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		done("timed out")
+		return "", fmt.Errorf("%s did not report its version within %s", phpPath, opts.probeTimeout)
+	}
+	if err != nil {
+		done("failed")
+		return "", fmt.Errorf("cannot run %s: %v", phpPath, err)
+	}
+	version := strings.TrimSpace(string(output))
+	done(version)
+	return version, nil
+}
+
+// verifyBinaryVersion checks that the binary configured for version really is
+// that version, e.g. that "8.2" runs a PHP 8.2.x and not a stale 8.1 install
+func verifyBinaryVersion(version, phpPath string) error {
+	expected, ok := parseVersionParts(versionKeyRe.FindString(version))
+	if !ok {
+		return fmt.Errorf("cannot verify non-numeric version %s", version)
+	}
+
+	reported, err := probeBinaryVersion(phpPath)
+	if err != nil {
+		return err
+	}
+	actual, ok := parseVersionParts(versionKeyRe.FindString(reported))
+	if !ok {
+		return fmt.Errorf("%s reported an unrecognised version %q", phpPath, reported)
+	}
+
+	// Only compare as many components as the config key specifies
+	if len(actual) > len(expected) {
+		actual = actual[:len(expected)]
+	}
+	if compareVersionParts(actual, expected) != 0 {
+		return fmt.Errorf("%s is configured as PHP %s but reports version %s", phpPath, version, reported)
+	}
+	return nil
+}
+
+// probeModules runs "php -m" and returns the loaded PHP and Zend extensions
+func probeModules(phpPath string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.probeTimeout)
+	defer cancel()
+
+	done := probeProgress(phpPath)
+	cmd := exec.CommandContext(ctx, phpPath, "-m")
+	cmd.WaitDelay = 100 * time.Millisecond
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		done("timed out")
+		return nil, fmt.Errorf("%s did not list its modules within %s", phpPath, opts.probeTimeout)
+	}
+	if err != nil {
+		done("failed")
+		return nil, fmt.Errorf("cannot run %s: %v", phpPath, err)
+	}
+	modules := parseModules(string(output))
+	done(fmt.Sprintf("%d modules", len(modules)))
+	return modules, nil
+}
+
+// parseModules extracts extension names from "php -m" output, skipping the
+// "[PHP Modules]"/"[Zend Modules]" headers and listing each module once
+func parseModules(output string) []string {
+	var modules []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(name, "[") || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		modules = append(modules, name)
+	}
+	return modules
+}
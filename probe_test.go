@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeCurrentPhpVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string // the php on PATH, or "" for none
+		want    string
+		wantErr error
+	}{
+		{name: "answers", script: `echo "PHP 8.3.4 (cli) (built: Mar 12 2024)"`, want: "8.3"},
+		{name: "stalls", script: "sleep 5", wantErr: errPathPHPTimeout},
+		{name: "missing", wantErr: errNoPathPHP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			opts.probeTimeout = 200 * time.Millisecond
+			bin := t.TempDir()
+			if tt.script != "" {
+				writeStub(t, filepath.Join(bin, "php"), tt.script)
+			}
+			// The stubs need sleep, so keep the rest of PATH after them
+			path := bin + string(os.PathListSeparator) + os.Getenv("PATH")
+			if tt.script == "" {
+				path = bin
+			}
+			t.Setenv("PATH", path)
+
+			start := time.Now()
+			version, err := probeCurrentPhpVersion()
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("probe took %s, want it cut off near the timeout", elapsed)
+			}
+			if version != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("probe = %q, %v, want %q, %v", version, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveTimeoutFallsThrough(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string // the php on PATH
+		want   string
+	}{
+		{name: "stalled", script: "sleep 5", want: "8.1"},
+		{name: "answering", script: `echo "PHP 8.2.4 (cli)"`, want: "8.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			bin := filepath.Join(root, "bin")
+			writeStub(t, filepath.Join(bin, "php"), tt.script)
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n"
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config+"default: 8.1\n")
+
+			start := time.Now()
+			stdout, stderr, code := runRunner(t, root, []string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"), "PHP_RUNNER_CONFIG=" + configPath}, "--resolve-timeout", "200ms", "current")
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("current took %s with a stalled php on PATH", elapsed)
+			}
+			if code != 0 || strings.TrimSpace(stdout) != tt.want {
+				t.Errorf("current = %q (exit %d, %s), want %s", stdout, code, stderr, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBinaryVersion(t *testing.T) {
+	tests := []struct {
+		key      string
+		reported string
+		wantErr  string
+	}{
+		{key: "8.2", reported: "8.2.10"},
+		{key: "8.2.10", reported: "8.2.10"},
+		{key: "8.2-zts", reported: "8.2.3"},
+		{key: "8.2", reported: "8.1.27", wantErr: "is configured as PHP 8.2 but reports version 8.1.27"},
+		{key: "8.2.10", reported: "8.2.11", wantErr: "is configured as PHP 8.2.10 but reports version 8.2.11"},
+		{key: "8.2", reported: "garbage", wantErr: `reported an unrecognised version "garbage"`},
+		{key: "stable", reported: "8.2.10", wantErr: "cannot verify non-numeric version stable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" reporting "+tt.reported, func(t *testing.T) {
+			isolate(t)
+			php := writeStub(t, filepath.Join(t.TempDir(), "php"), "echo "+tt.reported)
+			err := verifyBinaryVersion(tt.key, php)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyVersionFlag(t *testing.T) {
+	tests := []struct {
+		flag     string
+		wantCode int
+		wantRun  bool
+	}{
+		{flag: "--verify-version", wantRun: true},
+		{flag: "--verify-version=warn", wantRun: true},
+		{flag: "--verify-version=error", wantCode: exitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			// A stale binary: configured as 8.2 but really 8.1
+			php := writeStub(t, filepath.Join(root, "php8.2"), `if [ "$1" = -r ]; then echo 8.1.27; else echo ran; fi`)
+			configPath := writeFile(t, filepath.Join(root, configFileName), "8.2: "+php+"\n")
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, tt.flag, "x.php")
+			if code != tt.wantCode {
+				t.Errorf("exit code %d, want %d", code, tt.wantCode)
+			}
+			if ran := strings.Contains(stdout, "ran"); ran != tt.wantRun {
+				t.Errorf("PHP ran: %v, want %v", ran, tt.wantRun)
+			}
+			if !strings.Contains(stdout+stderr, "reports version 8.1.27") {
+				t.Errorf("output doesn't report the mismatch: %q %q", stdout, stderr)
+			}
+		})
+	}
+}
+
+func TestProbeProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		probe   func(phpPath string) error
+		verbose bool
+		want    string // progress line after the path
+	}{
+		{
+			name:    "version",
+			script:  "echo 8.2.30",
+			probe:   func(phpPath string) error { _, err := probeBinaryVersion(phpPath); return err },
+			verbose: true,
+			want:    "8.2.30",
+		},
+		{
+			name:    "modules",
+			script:  `printf '[PHP Modules]\ncore\njson\n\n[Zend Modules]\nZend OPcache\n'`,
+			probe:   func(phpPath string) error { _, err := probeModules(phpPath); return err },
+			verbose: true,
+			want:    "3 modules",
+		},
+		{
+			name:    "failed",
+			script:  "exit 1",
+			probe:   func(phpPath string) error { _, err := probeBinaryVersion(phpPath); return err },
+			verbose: true,
+			want:    "failed",
+		},
+		{
+			name:    "timed out",
+			script:  "exec sleep 5",
+			probe:   func(phpPath string) error { _, err := probeModules(phpPath); return err },
+			verbose: true,
+			want:    "timed out",
+		},
+		{
+			name:   "quiet",
+			script: "echo 8.2.30",
+			probe:  func(phpPath string) error { _, err := probeBinaryVersion(phpPath); return err },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			opts.verbose = tt.verbose
+			opts.probeTimeout = 200 * time.Millisecond
+			php := writeStub(t, filepath.Join(t.TempDir(), "php"), tt.script)
+
+			stderr := captureStderr(t, func() { tt.probe(php) })
+			want := ""
+			if tt.verbose {
+				want = "php-runner: probing " + php + "... " + tt.want + "\n"
+			}
+			if stderr != want {
+				t.Errorf("stderr = %q, want %q", stderr, want)
+			}
+		})
+	}
+}
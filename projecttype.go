@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// projectMarkers identify a framework by a file at the project root, in the
+// order they are checked within a directory
+var projectMarkers = []struct {
+	Type   string
+	Marker string
+}{
+	{"laravel", "artisan"},
+	{"symfony", filepath.Join("bin", "console")},
+}
+
+// detectProjectType looks in the current and parent directories, up to the
+// search boundary if bounded, for a framework's marker file and returns the
+// type of the nearest project
+func detectProjectType(startDir string, bounded bool) (string, string) {
+	var projectType, markerPath string
+	walkUp(startDir, bounded, func(dir string) bool {
+		for _, marker := range projectMarkers {
+			path := filepath.Join(dir, marker.Marker)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				projectType, markerPath = marker.Type, path
+				return true
+			}
+		}
+		return false
+	})
+	return projectType, markerPath
+}
+
+// projectTypeSource uses the type_default configured for the detected kind
+// of project, e.g. "type_default.laravel: 8.2"
+func projectTypeSource(cwd, searchDir string, config *Config) (string, error) {
+	if len(config.TypeDefaults) == 0 {
+		return "", nil
+	}
+	projectType, markerPath := detectProjectType(searchDir, searchBounded(config))
+	if projectType == "" {
+		return "", nil
+	}
+	version := config.TypeDefaults[projectType]
+	if version != "" {
+		verbosef("%s marks a %s project, which defaults to %s", markerPath, projectType, version)
+	}
+	return version, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectTypeSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string // files in the project
+		start string   // directory under the project to run from
+		pin   bool     // pin 8.3 in the project
+		want  string
+	}{
+		{name: "laravel", files: []string{"artisan"}, want: "8.2"},
+		{name: "symfony", files: []string{"bin/console"}, want: "7.4"},
+		{name: "from a subdirectory", files: []string{"artisan"}, start: "app/Http", want: "8.2"},
+		{name: "both markers", files: []string{"artisan", "bin/console"}, want: "8.2"},
+		{name: "marker is a directory", files: []string{"artisan/README"}, want: "8.3"},
+		{name: "no marker", files: []string{"index.php"}, want: "8.3"},
+		{name: "pin outranks type", files: []string{"artisan"}, pin: true, want: "8.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "type_default.laravel: 8.2\ntype_default.symfony: 7.4\n"
+			for _, version := range []string{"7.4", "8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, "project")
+			for _, name := range tt.files {
+				writeFile(t, filepath.Join(project, filepath.FromSlash(name)), "")
+			}
+			if tt.pin {
+				writeFile(t, filepath.Join(project, versionFile), "8.3\n")
+			}
+			start := filepath.Join(project, filepath.FromSlash(tt.start))
+			if err := os.MkdirAll(start, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			// Without a php on PATH the fallback is the highest installed version
+			env := []string{"PATH=" + t.TempDir()}
+			stdout, stderr, code := runRunner(t, start, env, "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSymlinksSearch(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	// The real tree pins 8.1; the directory holding the symlink pins 8.3
+	writeFile(t, filepath.Join(root, "real", "project", versionFile), "8.1\n")
+	writeFile(t, filepath.Join(root, "real", "project", "app", "index.php"), "")
+	writeFile(t, filepath.Join(root, "mount", versionFile), "8.3\n")
+	link := filepath.Join(root, "mount", "app")
+	if err := os.Symlink(filepath.Join(root, "real", "project", "app"), link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	var config string
+	for _, version := range []string{"8.1", "8.3"} {
+		config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+	}
+	writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+
+	tests := []struct {
+		name     string
+		realPath bool
+		want     string
+	}{
+		{name: "logical path", want: "8.3"},
+		{name: "real path", realPath: true, want: "8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The shell's PWD keeps the symlinked path, as it does in a terminal
+			args := []string{"current"}
+			if tt.realPath {
+				args = append([]string{"--resolve-symlinks"}, args...)
+			}
+			stdout, stderr, code := runRunner(t, link, []string{"PWD=" + link}, args...)
+			if code != 0 || strings.TrimSpace(stdout) != tt.want {
+				t.Errorf("current = %q (exit %d, %s), want %s", stdout, code, stderr, tt.want)
+			}
+		})
+	}
+}
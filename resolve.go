@@ -0,0 +1,730 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources a resolved version can come from
+const (
+	sourceResolver    = "resolver"
+	sourceVersionFile = "php-version"
+	sourceMise        = "mise"
+	sourceIdea        = "idea"
+	sourceDdev        = "ddev"
+	sourceComposer    = "composer"
+	sourceRule        = "rule"
+	sourceProjectType = "project-type"
+	sourceAlts        = "alternatives"
+	sourcePolicy      = "policy"
+	sourceByPath      = "by-path"
+	sourceEnv         = "env"
+	sourceArgument    = "argument"
+	sourceLock        = "composer.lock"
+	sourceWorkspace   = "workspace"
+	sourceGlobal      = "global"
+	sourcePath        = "path"
+	sourceDefault     = "default"
+	sourceFirst       = "first"
+)
+
+// Resolution describes the PHP version selected for a directory
+type Resolution struct {
+	Version string // config key of the selected version
+	Path    string // configured PHP executable path
+	Source  string // where the version came from, e.g. "php-version"
+}
+
+// resolveVersion determines which PHP version to use for cwd without any
+// side effects on disk
+func resolveVersion(cwd string, config *Config) (Resolution, error) {
+	searchDir := projectSearchDir(cwd)
+	var resolution Resolution
+	var err error
+	if opts.byPath != "" {
+		resolution, err = resolveByPath(config, opts.byPath)
+	} else if name, version, ok := strings.Cut(opts.versionArg, "="); ok {
+		resolution, err = resolveForcedVersion(config, name, version, sourceArgument)
+	} else if version := os.Getenv(versionEnvVar); version != "" {
+		resolution, err = resolveForcedVersion(config, versionEnvVar, version, sourceEnv)
+	} else {
+		// Composer-based modes decide when the project has what they need
+		decided := false
+		if opts.lockExact && !opts.noFileSearch {
+			resolution, decided, err = resolveLockExact(searchDir, config)
+		}
+		if !decided && opts.workspaceMin && !opts.noFileSearch {
+			resolution, decided, err = resolveWorkspaceMin(searchDir, config)
+		}
+		if !decided {
+			resolution, err = resolveSource(cwd, searchDir, config)
+		}
+	}
+	if err != nil {
+		return Resolution{}, err
+	}
+	if opts.consistency && resolution.Source == sourceVersionFile {
+		if err := checkComposerConsistency(searchDir, resolution.Version, searchBounded(config)); err != nil {
+			return Resolution{}, err
+		}
+	}
+	if resolution, err = applyPolicy(searchDir, config, resolution); err != nil {
+		return Resolution{}, err
+	}
+	verbosef("selected PHP %s at %s (from %s)", resolution.Version, resolution.Path, resolution.Source)
+	return resolution, nil
+}
+
+// resolveByPath selects the one configured version installed under prefix,
+// comparing whole path components so /opt/php8 doesn't match /opt/php82
+func resolveByPath(config *Config, prefix string) (Resolution, error) {
+	prefix = filepath.Clean(prefix)
+	var matches []string
+	for _, version := range configuredVersions(config) {
+		path := filepath.Clean(config.Versions[version])
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, string(filepath.Separator))+string(filepath.Separator)) {
+			matches = append(matches, version)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Resolution{}, fmt.Errorf("no configured PHP executable is under %s", prefix)
+	case 1:
+		return Resolution{Version: matches[0], Path: config.Versions[matches[0]], Source: sourceByPath}, nil
+	}
+	return Resolution{}, fmt.Errorf("several configured versions are under %s (%s); use a longer prefix", prefix, strings.Join(matches, ", "))
+}
+
+// versionEnvVar forces a version without touching any files, e.g. in CI
+const versionEnvVar = "PHP_RUNNER_VERSION"
+
+// resolveForcedVersion selects the version named by $PHP_RUNNER_VERSION or a
+// leading PHP=<version> argument, given as name. An unconfigured version is
+// an error rather than a fallback, so a typo in CI stops the build instead of
+// quietly running another PHP.
+func resolveForcedVersion(config *Config, name, version, source string) (Resolution, error) {
+	version, err := resolveAlias(config, strings.TrimSpace(version))
+	if err != nil {
+		return Resolution{}, fmt.Errorf("%s: %v", name, err)
+	}
+	if config.Versions[version] == "" {
+		err := fmt.Errorf("%s=%s is not a configured version (available: %s)",
+			name, version, strings.Join(configuredVersions(config), ", "))
+		if hint := installHint(config, version); hint != "" && opts.onMissing == onMissingInstallHint {
+			err = fmt.Errorf("%w\nTo install it: %s", err, hint)
+		}
+		return Resolution{}, err
+	}
+	return Resolution{Version: version, Path: config.Versions[version], Source: source}, nil
+}
+
+// projectSearchDir returns the directory project files are searched from.
+// Walking up from a path under a symlink or bind mount visits the link's
+// parents; optionally walk the real directory tree instead.
+func projectSearchDir(cwd string) string {
+	if opts.realPath {
+		if realDir, err := filepath.EvalSymlinks(cwd); err == nil {
+			return realDir
+		}
+	}
+	return cwd
+}
+
+// resolveSource returns the version named by the first source, in priority
+// order, that names a configured one, searching for project files from
+// searchDir
+func resolveSource(cwd, searchDir string, config *Config) (Resolution, error) {
+	for _, source := range sourceOrder(config) {
+		// Project files can be ignored entirely for deterministic sandboxed runs
+		if opts.noFileSearch && projectSources[source] {
+			continue
+		}
+		version, err := sourceLookups[source](cwd, searchDir, config)
+		if err != nil {
+			return Resolution{}, err
+		}
+		if version, err = resolveAlias(config, version); err != nil {
+			return Resolution{}, err
+		}
+		switch {
+		case version == "":
+			verbosef("%s source: no version", source)
+		case config.Versions[version] == "":
+			verbosef("%s source: %s, which is not configured", source, version)
+		default:
+			verbosef("%s source: %s", source, version)
+			return Resolution{Version: version, Path: config.Versions[version], Source: source}, nil
+		}
+	}
+	return Resolution{}, fmt.Errorf("no valid PHP version found")
+}
+
+// sourceLookup returns the version a source names for a directory, or "" if
+// it names none. The version need not be configured; resolveSource skips it
+// if it isn't.
+type sourceLookup func(cwd, searchDir string, config *Config) (string, error)
+
+// sourceLookups are the sources that can be listed in a priority order
+var sourceLookups = map[string]sourceLookup{
+	sourceResolver:    resolverSource,
+	sourceVersionFile: versionFileSource,
+	sourceMise:        miseSource,
+	sourceIdea:        ideaSource,
+	sourceDdev:        ddevSource,
+	sourceComposer:    composerSource,
+	sourceRule:        ruleSource,
+	sourceProjectType: projectTypeSource,
+	sourceAlts:        alternativesSource,
+	sourceGlobal:      globalSource,
+	sourcePath:        pathSource,
+	sourceDefault:     defaultSource,
+	sourceFirst:       firstSource,
+}
+
+// projectSources read files in the project, and are skipped under
+// --no-version-file-search
+var projectSources = map[string]bool{
+	sourceVersionFile: true,
+	sourceMise:        true,
+	sourceIdea:        true,
+	sourceDdev:        true,
+	sourceComposer:    true,
+	sourceProjectType: true,
+}
+
+// defaultSourceOrder is the priority used unless the config or --sources
+// gives another; the idea, ddev and alternatives sources are added only
+// with --idea-detect, --ddev and --alternatives
+var defaultSourceOrder = []string{
+	sourceResolver, sourceVersionFile, sourceMise, sourceIdea, sourceDdev, sourceComposer, sourceRule, sourceProjectType, sourceAlts, sourceGlobal, sourcePath, sourceDefault, sourceFirst,
+}
+
+// sourceOrder returns the sources to consult, highest priority first.
+// --sources wins over the config's sources setting.
+func sourceOrder(config *Config) []string {
+	if opts.sources != nil {
+		return opts.sources
+	}
+	if config.Sources != nil {
+		return config.Sources
+	}
+	var order []string
+	for _, source := range defaultSourceOrder {
+		if (source != sourceIdea || opts.ideaDetect) && (source != sourceDdev || opts.ddev) && (source != sourceAlts || opts.alternatives) {
+			order = append(order, source)
+		}
+	}
+	return order
+}
+
+// parseSourceOrder parses a priority list such as "php-version, default" or
+// "[php-version, default]"
+func parseSourceOrder(text string) ([]string, error) {
+	text = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(text), "["), "]")
+	order := []string{}
+	for _, source := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if sourceLookups[source] == nil {
+			return nil, fmt.Errorf("unknown source %q: expected one of %s", source, strings.Join(defaultSourceOrder, ", "))
+		}
+		order = append(order, source)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no sources listed")
+	}
+	return order, nil
+}
+
+// resolverSource asks the configured resolver script; it overrides every
+// other source by default
+func resolverSource(cwd, searchDir string, config *Config) (string, error) {
+	if config.Resolver == "" {
+		return "", nil
+	}
+	version := runResolver(config.Resolver, cwd)
+	if target, err := resolveAlias(config, version); err == nil && version != "" && config.Versions[target] == "" {
+		warnf("resolver %s returned unconfigured version %s", config.Resolver, version)
+	}
+	return version, nil
+}
+
+// versionFileSource looks for a .php-version file in the current directory
+// and its parents
+func versionFileSource(cwd, searchDir string, config *Config) (string, error) {
+	version, versionPath := findPhpVersionFile(searchDir, searchBounded(config))
+	version, err := resolveAlias(config, version)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", versionPath, err)
+	}
+	if version != "" && config.Versions[version] == "" && isVersionConstraint(version) {
+		return resolveVersionConstraint(config, version, versionPath)
+	}
+	if version == "" || config.Versions[version] != "" {
+		return version, nil
+	}
+
+	// Some tools pin a patch release such as 8.2.10; the config keys versions
+	// by major.minor, so use that release's minor version
+	if minor := minorVersion(version); minor != "" && config.Versions[minor] != "" {
+		verbosef("%s names PHP %s, which is not configured; using %s", versionPath, version, minor)
+		return minor, nil
+	}
+
+	// Most likely a typo such as 8.20 for 8.2; offer the nearest version
+	nearest := nearestVersion(config, version)
+	if nearest != "" && confirm(fmt.Sprintf("%s names PHP %s, which is not configured. Use %s instead?", versionPath, version, nearest)) {
+		return nearest, nil
+	}
+	if opts.onMissing == onMissingInstallHint {
+		return "", &missingVersionError{Version: version, File: versionPath, Nearest: nearest, Hint: installHint(config, version)}
+	}
+	if nearest != "" {
+		warnf("%s names PHP %s, which is not configured; did you mean %s?", versionPath, version, nearest)
+	}
+	return version, nil
+}
+
+// miseSource looks for a mise/rtx tool file declaring a php version
+func miseSource(cwd, searchDir string, config *Config) (string, error) {
+	version, _ := findMiseVersion(searchDir, searchBounded(config))
+	return version, nil
+}
+
+// ideaSource reads the language level from PhpStorm's project settings
+func ideaSource(cwd, searchDir string, config *Config) (string, error) {
+	version, _ := findIdeaVersion(searchDir, searchBounded(config))
+	return version, nil
+}
+
+// ddevSource reads the PHP version a DDEV project runs in its container
+func ddevSource(cwd, searchDir string, config *Config) (string, error) {
+	version, _ := findDdevVersion(searchDir, searchBounded(config))
+	return version, nil
+}
+
+// ruleSource applies the central policy: the first rule whose pattern
+// matches the directory wins
+func ruleSource(cwd, searchDir string, config *Config) (string, error) {
+	rule, ok := matchRule(config.Rules, cwd)
+	if !ok {
+		return "", nil
+	}
+	if target, err := resolveAlias(config, rule.Version); err == nil && config.Versions[target] == "" {
+		warnf("rule %s selects unconfigured version %s", rule.Pattern, rule.Version)
+	}
+	return rule.Version, nil
+}
+
+// globalSource uses the version saved with "use --global", which outranks
+// the php on PATH since it was chosen explicitly
+func globalSource(cwd, searchDir string, config *Config) (string, error) {
+	statePath := stateFile(globalStateName)
+	if statePath == "" {
+		return "", nil
+	}
+	content, err := os.ReadFile(statePath)
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// pathSource gets the version of the php currently on PATH
+func pathSource(cwd, searchDir string, config *Config) (string, error) {
+	version, err := getCurrentPhpVersion()
+	if err != nil {
+		// The defaults come next, so this isn't worth failing over
+		verbosef("path source skipped: %v", err)
+	}
+	return version, nil
+}
+
+// defaultSource uses the config's default, then the highest installed
+// version found on the first run, then the built-in default
+func defaultSource(cwd, searchDir string, config *Config) (string, error) {
+	if config.Default != "" {
+		version, err := resolveAlias(config, config.Default)
+		if err == nil && config.Versions[version] != "" {
+			return version, nil
+		}
+		if err != nil {
+			warnf("default version %s: %v", config.Default, err)
+		} else {
+			warnf("default version %s is not configured", config.Default)
+		}
+	}
+	if version := installedDefault(config); version != "" {
+		return version, nil
+	}
+	return defaultVersion, nil
+}
+
+// firstSource uses any configured version
+func firstSource(cwd, searchDir string, config *Config) (string, error) {
+	for version := range config.Versions {
+		return version, nil
+	}
+	return "", nil
+}
+
+// getPhpVersion determines which PHP version to use for running PHP, and
+// saves a default picked for the first time. With --pin or PHP_RUNNER_PIN a
+// fallback choice is also recorded in a new .php-version file so later runs
+// stay consistent; by default no .php-version is written.
+func getPhpVersion(cwd string, config *Config) Resolution {
+	resolution, err := resolveVersion(cwd, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCodeFor(config, outcomeVersionUnmatched))
+	}
+
+	if resolution.Source == sourceDefault && resolution.Version == unsavedDefault {
+		saveInstalledDefault(resolution.Version)
+	}
+
+	// Without the file search an existing pin could be overwritten, so only
+	// write one when the project files were consulted
+	if opts.pin && isFallbackSource(resolution.Source) && !opts.noFileSearch {
+		createPhpVersionFile(cwd, resolution.Version, pinFileMode(config))
+	}
+	return resolution
+}
+
+// defaultStateName is the file next to the config that records the default
+// chosen on the first run
+const defaultStateName = ".php-runner-default"
+
+// globalStateName is the file next to the config that records the version
+// saved with "use --global"
+const globalStateName = ".php-runner-global"
+
+// unsavedDefault is the version installedDefault picked because none was
+// saved yet, for getPhpVersion to save once PHP is really run with it
+var unsavedDefault string
+
+// installedDefault returns the default version picked on the first run: the
+// highest configured version whose executable exists. It is saved next to
+// the config so later runs keep using it even as versions are installed,
+// and picked again only if the saved version is no longer configured.
+// Picking doesn't save it, so commands that only report the version don't
+// make the choice for good.
+func installedDefault(config *Config) string {
+	statePath := stateFile(defaultStateName)
+	if statePath == "" {
+		return ""
+	}
+	if content, err := os.ReadFile(statePath); err == nil {
+		if version := strings.TrimSpace(string(content)); config.Versions[version] != "" {
+			return version
+		}
+	}
+
+	unsavedDefault = highestInstalledVersion(config)
+	return unsavedDefault
+}
+
+// saveInstalledDefault records version as the first-run default
+func saveInstalledDefault(version string) {
+	statePath := stateFile(defaultStateName)
+	if err := writeFileAtomic(statePath, []byte(version+"\n"), 0644); err == nil {
+		fmt.Fprintf(os.Stderr, "Using PHP %s, the highest installed version, as the default (saved in %s)\n", version, statePath)
+	}
+}
+
+// highestInstalledVersion returns the highest numeric version whose local
+// executable exists; container images can't be checked and are skipped
+func highestInstalledVersion(config *Config) string {
+	versions := configuredVersions(config)
+	for i := len(versions) - 1; i >= 0; i-- {
+		path := config.Versions[versions[i]]
+		if _, ok := parseVersionParts(versions[i]); !ok {
+			continue
+		}
+		if _, _, isContainer := parseContainerPath(path); isContainer {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			verbosef("highest installed version is %s at %s", versions[i], path)
+			return versions[i]
+		}
+		verbosef("%s is not installed at %s", versions[i], path)
+	}
+	return ""
+}
+
+// isFallbackSource reports whether source is one of the guesses made when no
+// project file or resolver chose a version, which are worth pinning
+func isFallbackSource(source string) bool {
+	return source == sourceGlobal || source == sourcePath || source == sourceDefault || source == sourceFirst
+}
+
+// runResolver runs the configured resolver script with the directory as its
+// argument and returns the version it prints, or "" if it prints nothing or fails
+func runResolver(resolver, dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, resolver, dir)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	cmd.WaitDelay = 100 * time.Millisecond
+	output, err := cmd.Output()
+	if err != nil {
+		warnf("resolver %s failed: %v", resolver, err)
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// matchRule returns the first rule whose pattern matches dir, written with
+// forward slashes on every platform
+func matchRule(rules []versionRule, dir string) (versionRule, bool) {
+	path := filepath.ToSlash(dir)
+	for _, rule := range rules {
+		if rule.Pattern.MatchString(path) {
+			return rule, true
+		}
+	}
+	return versionRule{}, false
+}
+
+// Values for the search_boundary setting
+const (
+	boundaryProject = "project" // stop at the repository root or home directory
+	boundaryNone    = "none"    // search up to the filesystem root
+)
+
+// searchBounded reports whether project file searches stop at the
+// search_boundary
+func searchBounded(config *Config) bool {
+	return config.Boundary != boundaryNone
+}
+
+// Reasons walkUp stops before the filesystem root
+const (
+	stopHome        = "home directory"
+	stopProjectRoot = "project root"
+)
+
+// walkUp calls visit with startDir and then each of its parents until visit
+// returns true. If bounded, the walk ends after the first directory with a
+// .git entry, or before the home directory unless it started there, so a
+// stray file in $HOME doesn't reach into unrelated projects. It returns the
+// last directory reached and, if the boundary ended the walk, why.
+func walkUp(startDir string, bounded bool, visit func(dir string) bool) (string, string) {
+	home, _ := os.UserHomeDir()
+	dir := startDir
+	for {
+		if bounded && dir != startDir && home != "" && dir == filepath.Clean(home) {
+			return dir, stopHome
+		}
+		if visit(dir) {
+			return dir, ""
+		}
+		// .git is a file in worktrees and submodules, so any entry counts
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); bounded && err == nil {
+			return dir, stopProjectRoot
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, ""
+		}
+		dir = parent
+	}
+}
+
+// findPhpVersionFile looks for .php-version file in current and parent
+// directories, up to the search boundary if bounded, returning the version
+// and the file it was read from
+func findPhpVersionFile(startDir string, bounded bool) (string, string) {
+	var walk []walkStep
+	if opts.explainTree {
+		defer func() { printWalkTree(os.Stderr, startDir, walk) }()
+	}
+
+	var version, versionPath string
+	stopDir, stopped := walkUp(startDir, bounded, func(dir string) bool {
+		path := filepath.Join(dir, versionFile)
+		if content, err := os.ReadFile(path); err == nil {
+			if pinned := strings.TrimSpace(string(content)); pinned != "" {
+				walk = append(walk, walkStep{dir, "found " + pinned})
+				version, versionPath = pinned, path
+				return true
+			}
+			walk = append(walk, walkStep{dir, "empty " + versionFile + ", skipped"})
+		} else if target, linkErr := os.Readlink(path); linkErr == nil && os.IsNotExist(err) {
+			// A pin symlinked to a shared file whose target has gone away
+			warnf("ignoring %s: it is a symlink to %s, which does not exist", path, target)
+			walk = append(walk, walkStep{dir, "dangling symlink, skipped"})
+		} else {
+			walk = append(walk, walkStep{dir, "no " + versionFile})
+		}
+		return false
+	})
+	switch stopped {
+	case stopHome:
+		walk = append(walk, walkStep{stopDir, stopHome + ", search stopped"})
+	case stopProjectRoot:
+		walk[len(walk)-1].Result += ", " + stopProjectRoot + ", search stopped"
+	}
+
+	if version != "" {
+		verbosef("found %s pinning %s", versionPath, version)
+	} else {
+		verbosef("no %s in %s or above", versionFile, startDir)
+	}
+	return version, versionPath
+}
+
+// walkStep records what findPhpVersionFile found in one directory
+type walkStep struct {
+	Dir    string
+	Result string
+}
+
+// printWalkTree draws the directories a .php-version search visited as a
+// tree, from the highest one reached down to where the search started
+func printWalkTree(w io.Writer, startDir string, walk []walkStep) {
+	fmt.Fprintf(w, "%s search from %s:\n", versionFile, startDir)
+	for depth := range walk {
+		step := walk[len(walk)-1-depth]
+		name := step.Dir
+		if depth > 0 {
+			name = strings.Repeat("    ", depth-1) + "└── " + filepath.Base(step.Dir)
+		}
+		fmt.Fprintf(w, "  %-40s %s\n", name, step.Result)
+	}
+}
+
+// Reasons getCurrentPhpVersion can't tell the version of the php on PATH
+var (
+	errNoPathPHP      = errors.New("no php on PATH")
+	errPathPHPTimeout = errors.New("php on PATH timed out")
+)
+
+// currentPHP holds the probe of the php on PATH, made at most once per run
+var currentPHP struct {
+	once    sync.Once
+	version string
+	err     error
+}
+
+// getCurrentPhpVersion gets the version of PHP currently in PATH. The probe
+// runs once and its result is reused. The error wraps errNoPathPHP or
+// errPathPHPTimeout when there is no php or it didn't answer in time.
+func getCurrentPhpVersion() (string, error) {
+	currentPHP.once.Do(func() {
+		currentPHP.version, currentPHP.err = probeCurrentPhpVersion()
+	})
+	return currentPHP.version, currentPHP.err
+}
+
+// probeCurrentPhpVersion asks the php on PATH for its version
+func probeCurrentPhpVersion() (string, error) {
+	// A stalled binary (e.g. on a slow network mount) must not hang
+	// resolution, so give up after the probe timeout and fall through
+	ctx, cancel := context.WithTimeout(context.Background(), opts.probeTimeout)
+	defer cancel()
+
+	done := probeProgress("php on PATH")
+	cmd := exec.CommandContext(ctx, "php", "--version")
+	cmd.WaitDelay = 100 * time.Millisecond // don't wait on pipes held open by its children
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		done("timed out after " + opts.probeTimeout.String())
+		return "", fmt.Errorf("%w after %s", errPathPHPTimeout, opts.probeTimeout)
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		done("not found")
+		return "", errNoPathPHP
+	}
+	if err != nil {
+		done("failed: " + err.Error())
+		return "", fmt.Errorf("php --version: %v", err)
+	}
+
+	// Parse PHP version from output
+	// Expected format: "PHP 8.2.0 (cli) ..." or "PHP 8.2.0-dev ..."
+	re := regexp.MustCompile(`PHP (\d+\.\d+)`)
+	matches := re.FindStringSubmatch(string(output))
+	if len(matches) >= 2 {
+		done(matches[1])
+		return matches[1], nil
+	}
+
+	done("no version in output")
+	return "", fmt.Errorf("php --version printed no version")
+}
+
+// checkPathMismatch warns if the php on PATH isn't the version resolved for
+// the project, so a shell default that differs doesn't go unnoticed. Only
+// the components both versions have are compared, so 8.2 matches 8.2-zts.
+func checkPathMismatch(version string) {
+	current, err := getCurrentPhpVersion()
+	if errors.Is(err, errPathPHPTimeout) {
+		warnf("cannot compare with the php on PATH: %v", err)
+	}
+	if err != nil {
+		return
+	}
+	pathParts, _ := parseVersionParts(current)
+	selected, ok := parseVersionParts(versionKeyRe.FindString(version))
+	if !ok {
+		return
+	}
+	n := min(len(pathParts), len(selected))
+	if compareVersionParts(pathParts[:n], selected[:n]) != 0 {
+		warnf("the php on PATH is %s, but this project uses %s", current, version)
+	}
+}
+
+// pinFileMode returns the permissions for created .php-version files:
+// $PHP_RUNNER_FILE_MODE, then the config's file_mode, then 0644
+func pinFileMode(config *Config) os.FileMode {
+	if value := os.Getenv("PHP_RUNNER_FILE_MODE"); value != "" {
+		mode, err := parseFileMode(value)
+		if err == nil {
+			return mode
+		}
+		warnf("ignoring PHP_RUNNER_FILE_MODE: %v", err)
+	}
+	if config.FileMode != 0 {
+		return config.FileMode
+	}
+	return 0644
+}
+
+// createPhpVersionFile creates a .php-version file with the specified version
+func createPhpVersionFile(dir, version string, mode os.FileMode) {
+	// Read-only checkouts simply go without a pin rather than warning on
+	// every run
+	if !dirWritable(dir) {
+		return
+	}
+
+	versionPath := filepath.Join(dir, versionFile)
+	if err := writeVersionFile(versionPath, version, mode); err != nil {
+		warnf("could not create %s: %v", versionPath, err)
+	} else {
+		fmt.Printf("Created %s with PHP version %s\n", versionPath, version)
+	}
+}
+
+// writeVersionFile writes a .php-version pinning version with the given
+// permissions
+func writeVersionFile(versionPath, version string, mode os.FileMode) error {
+	if err := os.WriteFile(versionPath, []byte(version+"\n"), mode); err != nil {
+		return err
+	}
+	// WriteFile's mode is filtered by the umask, so apply it explicitly
+	return os.Chmod(versionPath, mode)
+}
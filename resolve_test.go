@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolutionErrorOnStderr(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		args []string
+	}{
+		{name: "environment", env: []string{"PHP_RUNNER_VERSION=9.9"}, args: []string{"x.php"}},
+		{name: "argument", args: []string{"PHP=9.9", "x.php"}},
+		{name: "constraint", args: []string{"x.php"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "echo ran")
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+php+"\n")
+			writeFile(t, filepath.Join(root, versionFile), "^9.0\n")
+			env := append([]string{"PHP_RUNNER_CONFIG=" + configPath}, tt.env...)
+
+			stdout, stderr, code := runRunner(t, root, env, tt.args...)
+			if code != exitUnavailable {
+				t.Errorf("exit code = %d, want %d", code, exitUnavailable)
+			}
+			if stdout != "" {
+				t.Errorf("stdout = %q, want nothing so PHP's output isn't mixed with errors", stdout)
+			}
+			if !strings.HasPrefix(stderr, "Error: ") {
+				t.Errorf("stderr = %q, want the error", stderr)
+			}
+		})
+	}
+}
+
+func TestRunnerErrorsOnStderr(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   bool // whether PHP_RUNNER_CONFIG names an existing file
+		args     []string
+		want     string // prefix of stderr
+		wantCode int
+	}{
+		{name: "usage", config: true, args: []string{"--reset"}, want: "Error: --reset only applies", wantCode: exitUsage},
+		{name: "config not found", args: []string{"x.php"}, want: "Error finding config file", wantCode: exitConfig},
+		{name: "stdin file", config: true, args: []string{"--stdin-file", "missing.txt", "x.php"}, want: "Error: cannot open stdin file", wantCode: exitCantCreate},
+		{name: "env file", config: true, args: []string{"--print-resolved-env-file", "no/such/dir/php.env"}, want: "Error writing no/such/dir/php.env", wantCode: exitCantCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "echo ran")
+			configPath := filepath.Join(root, "conf", configFileName)
+			if tt.config {
+				writeFile(t, configPath, "8.2: "+php+"\n")
+			}
+			writeFile(t, filepath.Join(root, versionFile), "8.2\n")
+
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if stdout != "" {
+				t.Errorf("stdout = %q, want nothing so PHP's output isn't mixed with errors", stdout)
+			}
+			if !strings.HasPrefix(stderr, tt.want) {
+				t.Errorf("stderr = %q, want it to start with %q", stderr, tt.want)
+			}
+		})
+	}
+}
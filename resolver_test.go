@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolverSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		want     string
+		wantWarn string
+	}{
+		{name: "returns a version", script: "echo 8.1", want: "8.1"},
+		{name: "returns nothing", script: "exit 0", want: "8.3"},
+		{name: "unconfigured version", script: "echo 7.4", want: "8.3", wantWarn: "returned unconfigured version 7.4"},
+		{name: "fails", script: "echo 8.1; exit 3", want: "8.3", wantWarn: "failed: exit status 3"},
+		{name: "given the directory", script: `[ "$1" = "$PWD" ] && echo 8.1`, want: "8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			resolver := writeStub(t, filepath.Join(root, "resolver"), tt.script)
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.3: " + writeStub(t, filepath.Join(root, "php8.3"), "exit 0") + "\n" +
+				"resolver: " + resolver + "\n"
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, "project")
+			// The resolver outranks the project's own pin
+			writeFile(t, filepath.Join(project, versionFile), "8.3\n")
+
+			stdout, stderr, code := runRunner(t, project, nil, "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+			if tt.wantWarn == "" && stderr != "" || !strings.Contains(stderr, tt.wantWarn) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantWarn)
+			}
+		})
+	}
+}
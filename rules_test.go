@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRuleSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		dir   string // project directory under the temp root
+		pin   string // .php-version in the project, if any
+		want  string
+	}{
+		{name: "match", rules: []string{"/clients/ => 8.2"}, dir: "clients/acme", want: "8.2"},
+		{name: "no match", rules: []string{"/clients/ => 8.2"}, dir: "internal/tool", want: "8.3"},
+		{name: "broad rule first wins", rules: []string{"/clients/ => 8.2", "/clients/legacy/ => 8.1"}, dir: "clients/legacy/app", want: "8.2"},
+		{name: "narrow rule first wins", rules: []string{"/clients/legacy/ => 8.1", "/clients/ => 8.2"}, dir: "clients/legacy/app", want: "8.1"},
+		{name: "later rule when earlier misses", rules: []string{"/internal/ => 8.1", "/clients/ => 8.2"}, dir: "clients/acme", want: "8.2"},
+		{name: "anchored pattern", rules: []string{"^/clients/ => 8.1"}, dir: "clients/acme", want: "8.3"},
+		{name: "pin outranks rule", rules: []string{"/clients/ => 8.2"}, dir: "clients/acme", pin: "8.1", want: "8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			for _, rule := range tt.rules {
+				config += "rule: " + rule + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, filepath.FromSlash(tt.dir))
+			writeFile(t, filepath.Join(project, "index.php"), "")
+			if tt.pin != "" {
+				writeFile(t, filepath.Join(project, versionFile), tt.pin+"\n")
+			}
+
+			stdout, stderr, code := runRunner(t, project, nil, "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMinRunnerVersion(t *testing.T) {
+	tests := []struct {
+		runner  string
+		minimum string
+		extra   string // config lines after the requirement
+		wantErr bool
+	}{
+		{runner: "1.3.9", minimum: "1.4.0", wantErr: true},
+		{runner: "1.4", minimum: "1.4.1", wantErr: true},
+		{runner: "1.4.0", minimum: "1.4.0"},
+		{runner: "v1.4.0", minimum: "1.4"},
+		{runner: "1.10.0", minimum: "1.4.0"},
+		{runner: "2.0.0", minimum: "v1.4.0"},
+		// Entries an older release would reject don't hide the requirement
+		{runner: "1.3.0", minimum: "1.5.0", extra: "env.8.2: NOT_AN_ASSIGNMENT\nsearch_boundary: everywhere\n", wantErr: true},
+		// Development builds aren't held back
+		{runner: "dev", minimum: "9.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.runner+" needs "+tt.minimum, func(t *testing.T) {
+			isolate(t)
+			saved := runnerVersion
+			runnerVersion = tt.runner
+			t.Cleanup(func() { runnerVersion = saved })
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "min_runner_version: "+tt.minimum+"\n8.2: "+php+"\n"+tt.extra)
+
+			_, err := loadConfig([]string{configPath})
+			if tt.wantErr {
+				want := "requires php-runner " + tt.minimum + " or newer, but this is " + tt.runner
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("loadConfig error = %v, want %q", err, want)
+				}
+			} else if err != nil {
+				t.Errorf("loadConfig error = %v", err)
+			}
+		})
+	}
+}
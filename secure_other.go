@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+// checkSecureBinary would refuse binaries other users can replace, for
+// --secure. Windows permissions are ACLs rather than mode bits, so nothing
+// is checked.
+func checkSecureBinary(phpPath string) error {
+	return nil
+}
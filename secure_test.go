@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecure(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    os.FileMode // the binary's permissions
+		dirMode os.FileMode // its directory's
+		link    os.FileMode // if set, configure it by a symlink in a directory with this mode
+		args    []string
+		errMsg  string // with {bin} for the binary, {path} for its configured path and {dir} for the writable directory
+	}{
+		{name: "safe", mode: 0755, dirMode: 0755, args: []string{"--secure"}},
+		{name: "read only", mode: 0555, dirMode: 0555, args: []string{"--secure"}},
+		{name: "group writable", mode: 0775, dirMode: 0755, args: []string{"--secure"}, errMsg: "{bin} is writable by other users (mode 0775)"},
+		{name: "world writable", mode: 0757, dirMode: 0755, args: []string{"--secure"}, errMsg: "{bin} is writable by other users (mode 0757)"},
+		{name: "world writable directory", mode: 0755, dirMode: 0777, args: []string{"--secure"}, errMsg: "{path} is in {dir}, which is world-writable (mode 0777)"},
+		{name: "sticky directory", mode: 0755, dirMode: 0777 | os.ModeSticky, args: []string{"--secure"}, errMsg: "{path} is in {dir}, which is world-writable (mode 0777)"},
+		{name: "safe link", mode: 0755, dirMode: 0755, link: 0755, args: []string{"--secure"}},
+		{name: "link to a world writable binary", mode: 0757, dirMode: 0755, link: 0755, args: []string{"--secure"}, errMsg: "{bin} is writable by other users (mode 0757)"},
+		{name: "link in a world writable directory", mode: 0755, dirMode: 0755, link: 0777, args: []string{"--secure"}, errMsg: "{path} is in {dir}, which is world-writable (mode 0777)"},
+		{name: "without --secure", mode: 0777, dirMode: 0777},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			dir := filepath.Join(root, "bin")
+			php := writeStub(t, filepath.Join(dir, "php8.2"), "echo ran")
+			configured, writableDir := php, dir
+			if tt.link != 0 {
+				linkDir := filepath.Join(root, "links")
+				if err := os.MkdirAll(linkDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				configured = filepath.Join(linkDir, "php")
+				if err := os.Symlink(php, configured); err != nil {
+					t.Fatal(err)
+				}
+				// Set explicitly, as the umask may have masked it
+				if err := os.Chmod(linkDir, tt.link); err != nil {
+					t.Fatal(err)
+				}
+				writableDir = linkDir
+			}
+			if err := os.Chmod(php, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(dir, tt.dirMode); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.Chmod(dir, 0755) })
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+configured+"\n")
+
+			args := append(append([]string{}, tt.args...), "x.php")
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, args...)
+			if tt.errMsg == "" {
+				if code != 0 || stdout != "ran\n" {
+					t.Errorf("exited %d with %q: %s", code, stdout, stderr)
+				}
+				return
+			}
+			want := "Error: refusing to run PHP 8.2 under --secure: " + strings.NewReplacer("{bin}", php, "{path}", configured, "{dir}", writableDir).Replace(tt.errMsg)
+			if code != exitUnavailable || !strings.Contains(stderr, want+"\n") {
+				t.Errorf("exited %d with %q, want %d and %q", code, stderr, exitUnavailable, want)
+			}
+			if strings.Contains(stdout, "ran") {
+				t.Error("the refused binary ran")
+			}
+		})
+	}
+}
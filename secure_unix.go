@@ -0,0 +1,39 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checkSecureBinary returns an error if other users could replace the PHP
+// binary at phpPath, for --secure: if the file it finally points at is group
+// or world writable, or the directory holding it or the configured link is
+// world writable
+func checkSecureBinary(phpPath string) error {
+	realPath, err := filepath.EvalSymlinks(phpPath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(realPath)
+	if err != nil {
+		return err
+	}
+	if perm := info.Mode().Perm(); perm&0022 != 0 {
+		return fmt.Errorf("%s is writable by other users (mode %04o)", realPath, perm)
+	}
+
+	for _, dir := range []string{filepath.Dir(phpPath), filepath.Dir(realPath)} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		// A sticky bit doesn't help: anyone may still add a file there
+		if perm := info.Mode().Perm(); perm&0002 != 0 {
+			return fmt.Errorf("%s is in %s, which is world-writable (mode %04o)", phpPath, dir, perm)
+		}
+	}
+	return nil
+}
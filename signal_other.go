@@ -0,0 +1,20 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// forwardedSignals are caught while php-runner waits on PHP. Windows
+// delivers Ctrl-C to every process on the console, so catching it keeps
+// php-runner around to report PHP's exit rather than dying first.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// forwardSignal does nothing: PHP has already received the console's
+// Ctrl-C, and Windows can't send it to a single process
+func forwardSignal(process *os.Process, sig os.Signal) {}
+
+// killedBy reports no signal: Windows processes always exit with a code,
+// even when terminated
+func killedBy(state *os.ProcessState) (int, bool) {
+	return 0, false
+}
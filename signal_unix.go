@@ -0,0 +1,26 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// forwardedSignals are relayed to PHP while php-runner waits on it, so an
+// interrupted or terminated runner doesn't leave PHP running on its own
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// forwardSignal relays sig to PHP
+func forwardSignal(process *os.Process, sig os.Signal) {
+	process.Signal(sig)
+}
+
+// killedBy returns the number of the signal that killed the process, if one did
+func killedBy(state *os.ProcessState) (int, bool) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return int(status.Signal()), true
+}
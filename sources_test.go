@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSourceOrder(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []string
+		wantErr string
+	}{
+		{text: "mise,php-version,default", want: []string{"mise", "php-version", "default"}},
+		{text: "[mise, php-version, default]", want: []string{"mise", "php-version", "default"}},
+		{text: " path ", want: []string{"path"}},
+		{text: "php-version, nvm", wantErr: `unknown source "nvm"`},
+		{text: "[]", wantErr: "no sources listed"},
+	}
+	for _, tt := range tests {
+		got, err := parseSourceOrder(tt.text)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseSourceOrder(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+			}
+		} else if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSourceOrder(%q) = %q, %v, want %q", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestSourceOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string   // sources setting, if any
+		args    []string // php-runner flags
+		want    string
+		wantErr string
+	}{
+		{name: "built-in order", want: "8.1"},
+		{name: "mise first", config: "[mise, php-version, default]", want: "8.2"},
+		{name: "default first", args: []string{"--sources", "default,php-version"}, want: "8.3"},
+		{name: "flag overrides config", config: "[mise, php-version]", args: []string{"--sources", "php-version"}, want: "8.1"},
+		{name: "unknown source", args: []string{"--sources", "nvm"}, wantErr: `unknown source "nvm"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			var config string
+			for _, version := range []string{"8.1", "8.2", "8.3"} {
+				config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), "exit 0") + "\n"
+			}
+			if tt.config != "" {
+				config += "sources: " + tt.config + "\n"
+			}
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			// Each source names a different version
+			project := filepath.Join(root, "project")
+			writeFile(t, filepath.Join(project, versionFile), "8.1\n")
+			writeFile(t, filepath.Join(project, ".mise.toml"), "[tools]\nphp = \"8.2\"\n")
+
+			stdout, stderr, code := runRunner(t, project, nil, append(tt.args, "current")...)
+			if tt.wantErr != "" {
+				if code == 0 || !strings.Contains(stdout+stderr, tt.wantErr) {
+					t.Errorf("current exited %d with %q, want an error containing %q", code, stdout+stderr, tt.wantErr)
+				}
+				return
+			}
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configDir is the directory of the config file in use, where php-runner
+// also keeps its state files; it is empty until the config has been found
+var configDir string
+
+// stateFile returns the path of a state file kept next to the config, or ""
+// if no config has been found
+func stateFile(name string) string {
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, name)
+}
+
+// writeFileAtomic replaces path with data so that readers see either the old
+// contents or the new, never a partial write: the data goes to a temporary
+// file in the same directory which is then renamed over path. Concurrent
+// writers can't corrupt the file; the last rename wins.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) // no-op once renamed
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Chmod(mode); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
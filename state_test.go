@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentCacheWrites(t *testing.T) {
+	const writers, entries = 20, 50
+	statePath := filepath.Join(t.TempDir(), moduleCacheName)
+
+	// Each writer saves a whole cache of its own, so a file mixing writers
+	// or missing entries would be a corrupted write
+	caches := make([]map[string]moduleCacheEntry, writers)
+	for i := range caches {
+		caches[i] = make(map[string]moduleCacheEntry)
+		for j := 0; j < entries; j++ {
+			caches[i][fmt.Sprintf("/opt/php%d/bin/php", j)] = moduleCacheEntry{
+				Stamp:   fmt.Sprintf("writer%d", i),
+				Modules: []string{"core", "json", strings.Repeat("x", 100*i)},
+			}
+		}
+	}
+	check := func(cache map[string]moduleCacheEntry) error {
+		if len(cache) == 0 {
+			return nil // not written yet
+		}
+		if len(cache) != entries {
+			return fmt.Errorf("%d entries, want %d", len(cache), entries)
+		}
+		var stamp string
+		for path, entry := range cache {
+			if stamp == "" {
+				stamp = entry.Stamp
+			}
+			if entry.Stamp != stamp || len(entry.Modules) == 0 {
+				return fmt.Errorf("%s = %+v, mixed with %s", path, entry, stamp)
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*10)
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 10; n++ {
+				if err := writeModuleCache(statePath, caches[i]); err != nil {
+					errs <- err
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 10; n++ {
+				if err := check(readModuleCache(statePath)); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if err := check(readModuleCache(statePath)); err != nil || len(readModuleCache(statePath)) == 0 {
+		t.Errorf("final cache: %v", err)
+	}
+	// No temporary files are left behind
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(statePath), ".*.tmp*"))
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left: %q", leftovers)
+	}
+}
+
+func TestConcurrentCacheProcesses(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	var config string
+	for _, version := range []string{"8.1", "8.2", "8.3"} {
+		config += version + ": " + writeStub(t, filepath.Join(root, "php"+version), `printf '[PHP Modules]\ncore\next`+version+`\n'`) + "\n"
+	}
+	configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+
+	// Processes racing to fill the cache each save the modules they probed
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, "ext-diff", "8.1", "8.3"); code > 1 {
+				t.Errorf("ext-diff exited %d: %s", code, stderr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	content, err := os.ReadFile(filepath.Join(root, "conf", moduleCacheName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) != 3 || !strings.HasPrefix(fields[2], "core,ext") {
+			t.Errorf("cache line %q is damaged", line)
+		}
+	}
+	if cache := readModuleCache(filepath.Join(root, "conf", moduleCacheName)); len(cache) != 2 {
+		t.Errorf("cache has %d binaries, want 2", len(cache))
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCurrentSymlinkFlip(t *testing.T) {
+	isolate(t)
+	root := t.TempDir()
+	configDir = t.TempDir()
+	// Same size and modification time, so only the resolved path tells the
+	// binaries apart in the module cache
+	stamp := time.Now().Add(-time.Hour)
+	for _, version := range []string{"8.1", "8.2"} {
+		php := writeStub(t, filepath.Join(root, version, "bin", "php"), `case "$1" in
+-m) printf '[PHP Modules]\ncore\next`+version[2:]+`\n' ;;
+-r) echo `+version+`.30 ;;
+*) echo ran `+version+` ;;
+esac`)
+		if err := os.Chtimes(php, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current := filepath.Join(root, "current")
+	phpPath := filepath.Join(current, "bin", "php")
+	configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+phpPath+"\n")
+
+	tests := []struct {
+		target      string
+		wantVersion string
+		wantModules []string
+	}{
+		{"8.1", "8.1.30", []string{"core", "ext1"}},
+		{"8.2", "8.2.30", []string{"core", "ext2"}},
+		{"8.1", "8.1.30", []string{"core", "ext1"}},
+	}
+	for _, tt := range tests {
+		os.Remove(current)
+		if err := os.Symlink(filepath.Join(root, tt.target), current); err != nil {
+			t.Fatal(err)
+		}
+
+		if version, err := probeBinaryVersion(phpPath); err != nil || version != tt.wantVersion {
+			t.Errorf("-> %s: version = %q, %v, want %s", tt.target, version, err, tt.wantVersion)
+		}
+		if modules, err := cachedModules(phpPath); err != nil || !slices.Equal(modules, tt.wantModules) {
+			t.Errorf("-> %s: modules = %q, %v, want %q", tt.target, modules, err, tt.wantModules)
+		}
+		stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath}, "x.php")
+		if want := "ran " + tt.target; code != 0 || strings.TrimSpace(stdout) != want {
+			t.Errorf("-> %s: running PHP printed %q (exit %d, %s), want %q", tt.target, stdout, code, stderr, want)
+		}
+	}
+
+	// Each target was probed once; the return to 8.1 was a cache hit
+	stats, _ := readCacheStats(stateFile(cacheStatsName))
+	if stats["modules hits"] != 1 || stats["modules misses"] != 2 {
+		t.Errorf("hits, misses = %d, %d, want 1, 2", stats["modules hits"], stats["modules misses"])
+	}
+}
+
+func TestSymlinkedPin(t *testing.T) {
+	tests := []struct {
+		name     string
+		shared   string // contents of the shared pin, or "" if it has gone away
+		want     string
+		wantWarn bool
+	}{
+		{name: "shared file", shared: "8.1\n", want: "8.1"},
+		{name: "dangling", want: "8.2", wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n"
+			writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			shared := filepath.Join(root, "shared", versionFile)
+			if tt.shared != "" {
+				writeFile(t, shared, tt.shared)
+			}
+			project := filepath.Join(root, "project")
+			if err := os.MkdirAll(project, 0755); err != nil {
+				t.Fatal(err)
+			}
+			pin := filepath.Join(project, versionFile)
+			if err := os.Symlink(shared, pin); err != nil {
+				t.Fatal(err)
+			}
+
+			stdout, stderr, code := runRunner(t, project, nil, "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+			warning := "ignoring " + pin + ": it is a symlink to " + shared + ", which does not exist"
+			if warned := strings.Contains(stderr, warning); warned != tt.wantWarn {
+				t.Errorf("stderr = %q, want warning %v", stderr, tt.wantWarn)
+			}
+		})
+	}
+}
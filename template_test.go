@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolutionTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		noConfig bool   // write no config file
+		want     string // stdout, with {{php}} for the stub's path
+		wantErr  string
+		wantCode int
+	}{
+		{name: "current", args: []string{"current", "--template", "{{.Version}} at {{.Path}} ({{.Source}})"}, want: "8.2 at {{php}} (php-version)\n"},
+		{name: "which", args: []string{"which", "--template", "PHP_BINARY={{.Path}}"}, want: "PHP_BINARY={{php}}\n"},
+		{name: "functions", args: []string{"current", "--template", `{{printf "%q" .Version}}`}, want: "\"8.2\"\n"},
+		{name: "shell escaped", args: []string{"current", "--shell-escape", "--template", "{{.Version}} ({{.Source}})"}, want: "'8.2 (php-version)'\n"},
+		{name: "syntax error", args: []string{"current", "--template", "{{.Version"}, noConfig: true, wantErr: "Invalid --template: ", wantCode: 2},
+		{name: "unknown field", args: []string{"current", "--template", "{{.Binary}}"}, wantErr: "can't evaluate field Binary", wantCode: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), "exit 0")
+			// A bad template is reported before the config is loaded
+			if !tt.noConfig {
+				writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), "8.2: "+php+"\n")
+			}
+			writeFile(t, filepath.Join(root, versionFile), "8.2\n")
+
+			stdout, stderr, code := runRunner(t, root, nil, tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if tt.wantErr != "" {
+				if stdout != "" || !strings.Contains(stderr, tt.wantErr) {
+					t.Errorf("stdout, stderr = %q, %q, want only the error %q", stdout, stderr, tt.wantErr)
+				}
+				return
+			}
+			if want := strings.ReplaceAll(tt.want, "{{php}}", php); stdout != want {
+				t.Errorf("stdout = %q, want %q", stdout, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// traceExec prints the command about to run, its directory and every
+// variable of its environment to w for --trace-exec. Values of variables
+// whose names match one of the redact patterns are hidden.
+func traceExec(w io.Writer, cmd *exec.Cmd, redact []string) {
+	fmt.Fprintf(w, "php-runner: exec %s\n", strings.Join(cmd.Args, " "))
+	fmt.Fprintf(w, "php-runner: dir %s\n", cmd.Dir)
+	for _, variable := range cmd.Env {
+		name, _, _ := strings.Cut(variable, "=")
+		if redactedName(name, redact) {
+			variable = name + "=<redacted>"
+		}
+		fmt.Fprintf(w, "php-runner: env %s\n", variable)
+	}
+}
+
+// printCommand prints the binary cmd runs and its arguments on one line,
+// quoted so the line can be pasted into a shell, for --dry-run
+func printCommand(w io.Writer, cmd *exec.Cmd) {
+	words := []string{shellQuote(cmd.Path)}
+	for _, arg := range cmd.Args[1:] {
+		words = append(words, shellQuote(arg))
+	}
+	fmt.Fprintln(w, strings.Join(words, " "))
+}
+
+// redactedName reports whether an environment variable name matches one of
+// the shell-style patterns, such as "*_SECRET"
+func redactedName(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRedactedName(t *testing.T) {
+	patterns := []string{"*_SECRET", "API_*", "PASSWORD"}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"DB_SECRET", true},
+		{"API_TOKEN", true},
+		{"PASSWORD", true},
+		{"DB_SECRET_FILE", false},
+		{"MY_API_TOKEN", false},
+		{"PASSWORDS", false},
+		{"APP_ENV", false},
+	}
+	for _, tt := range tests {
+		if got := redactedName(tt.name, patterns); got != tt.want {
+			t.Errorf("redactedName(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTraceExec(t *testing.T) {
+	tests := []struct {
+		name     string
+		redact   []string
+		want     []string // lines in stderr
+		unwanted []string
+	}{
+		{
+			name: "values shown",
+			want: []string{"php-runner: env APP_ENV=test", "php-runner: env DB_SECRET=hunter2", "php-runner: env API_TOKEN=abc123"},
+		},
+		{
+			name:     "values redacted",
+			redact:   []string{"*_SECRET", "API_*"},
+			want:     []string{"php-runner: env APP_ENV=test", "php-runner: env DB_SECRET=<redacted>", "php-runner: env API_TOKEN=<redacted>"},
+			unwanted: []string{"hunter2", "abc123"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php8.2"), `echo "$APP_ENV $DB_SECRET $API_TOKEN"`)
+			config := "8.2: " + php + "\nenv.8.2: APP_ENV=test\nenv.8.2: DB_SECRET=hunter2\n"
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			writeFile(t, filepath.Join(root, versionFile), "8.2\n")
+
+			args := []string{"--trace-exec"}
+			for _, pattern := range tt.redact {
+				args = append(args, "--redact", pattern)
+			}
+			args = append(args, "x.php")
+			stdout, stderr, code := runRunner(t, root, []string{"PHP_RUNNER_CONFIG=" + configPath, "API_TOKEN=abc123"}, args...)
+			if code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			// Redaction only affects the trace
+			if stdout != "test hunter2 abc123\n" {
+				t.Errorf("PHP saw %q", stdout)
+			}
+			lines := strings.Split(stderr, "\n")
+			for _, line := range append(tt.want, "php-runner: exec "+php+" x.php", "php-runner: dir "+root) {
+				if !slices.Contains(lines, line) {
+					t.Errorf("stderr = %q, want line %q", stderr, line)
+				}
+			}
+			for _, value := range tt.unwanted {
+				if strings.Contains(stderr, value) {
+					t.Errorf("stderr = %q, want %q hidden", stderr, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUseGlobal(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  string // version saved with use --global, if any
+		pin     string // .php-version in the project, if any
+		sources string // sources setting, if any
+		want    string
+	}{
+		{name: "path without a global version", want: "8.1"},
+		{name: "global outranks path", global: "8.3", want: "8.3"},
+		{name: "pin outranks global", global: "8.3", pin: "8.2", want: "8.2"},
+		{name: "left out of sources", global: "8.3", sources: "[path, default]", want: "8.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			bin := filepath.Join(root, "bin")
+			writeStub(t, filepath.Join(bin, "php"), `echo "PHP 8.1.30 (cli)"`)
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n" +
+				"8.3: " + writeStub(t, filepath.Join(root, "php8.3"), "exit 0") + "\n"
+			if tt.sources != "" {
+				config += "sources: " + tt.sources + "\n"
+			}
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), config)
+			project := filepath.Join(root, "project")
+			if err := os.MkdirAll(project, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.pin != "" {
+				writeFile(t, filepath.Join(project, versionFile), tt.pin+"\n")
+			}
+			env := []string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"), "PHP_RUNNER_CONFIG=" + configPath}
+
+			if tt.global != "" {
+				stdout, stderr, code := runRunner(t, project, env, "use", "--global", tt.global)
+				if code != 0 {
+					t.Fatalf("use --global exited %d: %s", code, stderr)
+				}
+				statePath := filepath.Join(root, "conf", globalStateName)
+				if !strings.Contains(stdout, statePath) {
+					t.Errorf("use --global printed %q, want it to name %s", stdout, statePath)
+				}
+				if warned := strings.Contains(stderr, "won't be used"); warned != (tt.sources != "") {
+					t.Errorf("use --global stderr = %q, want a warning only when global isn't a source", stderr)
+				}
+			}
+
+			stdout, stderr, code := runRunner(t, project, env, "current")
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		want     []string // reported duplicates, as "<key> is defined more than once (lines ...); line N wins"
+		wantCode int
+	}{
+		{
+			name:   "flat without duplicates",
+			config: "8.1: {php}\n8.2: {php}\ndefault: 8.2\n",
+		},
+		{
+			name:     "flat duplicate version",
+			config:   "8.2: {php}\n8.1: {php}\n8.2: /opt/php8.2\n",
+			want:     []string{"8.2 is defined more than once (lines 1, 3); line 3 wins"},
+			wantCode: 1,
+		},
+		{
+			name:     "flat duplicate setting",
+			config:   "8.2: {php}\ndefault: 8.2\n# a comment\ndefault: 8.1\n",
+			want:     []string{"default is defined more than once (lines 2, 4); line 4 wins"},
+			wantCode: 1,
+		},
+		{
+			name:   "nested without duplicates",
+			config: "versions:\n  8.1: {php}\n  8.2: {php}\naliases:\n  stable: 8.2\n",
+		},
+		{
+			name:     "nested duplicate version and alias",
+			config:   "versions:\n  8.2: {php}\n  8.2: /opt/php8.2\naliases:\n  stable: 8.1\n  stable: 8.2\n",
+			want:     []string{"8.2 is defined more than once (lines 2, 3); line 3 wins", "alias.stable is defined more than once (lines 5, 6); line 6 wins"},
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			php := writeStub(t, filepath.Join(root, "php"), "exit 0")
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), strings.ReplaceAll(tt.config, "{php}", php))
+
+			stdout, stderr, code := runRunner(t, root, nil, "config", "validate")
+			if code != tt.wantCode {
+				t.Fatalf("exit code %d, want %d: %s%s", code, tt.wantCode, stdout, stderr)
+			}
+			var want []string
+			for _, duplicate := range tt.want {
+				want = append(want, configPath+": "+duplicate)
+			}
+			if len(want) == 0 {
+				want = []string{configPath + ": OK"}
+			}
+			if got := strings.Split(strings.TrimSpace(stdout), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("output:\n%s\nwant:\n%s", stdout, strings.Join(want, "\n"))
+			}
+		})
+	}
+}
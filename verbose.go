@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// verbosef prints a progress or trace message to stderr with --verbose or
+// PHP_RUNNER_VERBOSE, keeping PHP's own output on stdout clean
+func verbosef(format string, args ...any) {
+	if opts.verbose {
+		fmt.Fprintf(os.Stderr, "php-runner: "+format+"\n", args...)
+	}
+}
+
+// probeProgress announces a probe of phpPath in verbose mode and returns a
+// function that completes the line with the probe's result, so a slow probe
+// shows what it is waiting on
+func probeProgress(phpPath string) func(result string) {
+	if !opts.verbose {
+		return func(string) {}
+	}
+	fmt.Fprintf(os.Stderr, "php-runner: probing %s... ", phpPath)
+	return func(result string) {
+		fmt.Fprintln(os.Stderr, result)
+	}
+}
+
+// envEnabled reports whether a boolean environment variable is switched on;
+// anything but empty, "0" and "false" counts
+func envEnabled(name string) bool {
+	switch os.Getenv(name) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
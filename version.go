@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseVersionParts splits a version such as "8.2.10" into its numeric
+// components. It returns false if any component is not a number.
+func parseVersionParts(version string) ([]int, bool) {
+	fields := strings.Split(strings.TrimSpace(version), ".")
+	parts := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// minorVersion returns the major.minor prefix of a major.minor.patch version
+// such as 8.2.10, or "" for anything else
+func minorVersion(version string) string {
+	parts, ok := parseVersionParts(version)
+	if !ok || len(parts) != 3 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", parts[0], parts[1])
+}
+
+// compareVersionParts compares two parsed versions component by component,
+// treating missing components as zero. It returns -1, 0 or 1.
+func compareVersionParts(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
+// formatVersionParts joins version components back into dotted form
+func formatVersionParts(parts []int) string {
+	fields := make([]string, len(parts))
+	for i, n := range parts {
+		fields[i] = strconv.Itoa(n)
+	}
+	return strings.Join(fields, ".")
+}
+
+// lastMinorRelease records the final minor release of each finished PHP major
+// line, and nextMajorRelease the major that followed it (PHP 6 was never
+// released, so 5.6 was followed by 7.0)
+var (
+	lastMinorRelease = map[int]int{5: 6, 7: 4}
+	nextMajorRelease = map[int]int{5: 7}
+)
+
+// upgradePath lists the major.minor releases passed through when upgrading
+// from one version to another, ending with the target itself. It is empty
+// if to is not newer than from. The final minor release of a major line
+// that is still current isn't known, so an upgrade past one goes straight
+// to the next major's .0 release, and jumpedFrom names the release the
+// uncounted gap starts at.
+func upgradePath(from, to []int) (path []string, jumpedFrom string) {
+	major, minor := from[0], from[1]
+	for major < to[0] || (major == to[0] && minor < to[1]) {
+		last, finished := lastMinorRelease[major]
+		switch {
+		case finished && minor >= last:
+			if next, ok := nextMajorRelease[major]; ok {
+				major = next
+			} else {
+				major++
+			}
+			minor = 0
+		case !finished && major < to[0]:
+			jumpedFrom = fmt.Sprintf("%d.%d", major, minor)
+			major, minor = major+1, 0
+		default:
+			minor++
+		}
+		path = append(path, fmt.Sprintf("%d.%d", major, minor))
+	}
+	return path, jumpedFrom
+}
+
+// sortVersions orders version keys numerically ("8.10" after "8.9"), placing
+// keys that aren't plain numbers after the numeric ones in string order
+func sortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, aok := parseVersionParts(versions[i])
+		b, bok := parseVersionParts(versions[j])
+		switch {
+		case aok && bok:
+			if c := compareVersionParts(a, b); c != 0 {
+				return c < 0
+			}
+			return versions[i] < versions[j]
+		case aok != bok:
+			return aok
+		}
+		return versions[i] < versions[j]
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUpgradePath(t *testing.T) {
+	tests := []struct {
+		name       string
+		from, to   []int
+		want       []string
+		jumpedFrom string
+	}{
+		{"adjacent", []int{8, 1}, []int{8, 2}, []string{"8.2"}, ""},
+		{"multi-step", []int{8, 1}, []int{8, 4}, []string{"8.2", "8.3", "8.4"}, ""},
+		{"patch ignored", []int{8, 1, 27}, []int{8, 2, 3}, []string{"8.2"}, ""},
+		{"across 7.4", []int{7, 3}, []int{8, 1}, []string{"7.4", "8.0", "8.1"}, ""},
+		{"across the missing PHP 6", []int{5, 5}, []int{7, 1}, []string{"5.6", "7.0", "7.1"}, ""},
+		{"past a current major", []int{8, 3}, []int{9, 0}, []string{"9.0"}, "8.3"},
+		{"past a current major into its minors", []int{8, 1}, []int{9, 2}, []string{"9.0", "9.1", "9.2"}, "8.1"},
+		{"same version", []int{8, 2}, []int{8, 2}, nil, ""},
+		{"downgrade", []int{8, 2}, []int{7, 4}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, jumpedFrom := upgradePath(tt.from, tt.to)
+			if !slices.Equal(path, tt.want) || jumpedFrom != tt.jumpedFrom {
+				t.Errorf("upgradePath(%v, %v) = %v, %q; want %v, %q", tt.from, tt.to, path, jumpedFrom, tt.want, tt.jumpedFrom)
+			}
+		})
+	}
+}
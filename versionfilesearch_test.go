@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNoVersionFileSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		pin  string // where the .php-version pinning 8.1 is, relative to the project
+		args []string
+		want string
+	}{
+		{name: "pin honoured", pin: ".", want: "8.1"},
+		{name: "parent pin honoured", pin: "..", want: "8.1"},
+		{name: "pin ignored", pin: ".", args: []string{"--no-version-file-search"}, want: "8.2"},
+		{name: "parent pin ignored", pin: "..", args: []string{"--no-version-file-search"}, want: "8.2"},
+		{name: "no pin", args: []string{"--no-version-file-search"}, want: "8.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			// The php on PATH is the next source after the project's files
+			bin := filepath.Join(root, "bin")
+			writeStub(t, filepath.Join(bin, "php"), `echo "PHP 8.2.12 (cli)"`)
+			config := "8.1: " + writeStub(t, filepath.Join(root, "php8.1"), "exit 0") + "\n" +
+				"8.2: " + writeStub(t, filepath.Join(root, "php8.2"), "exit 0") + "\n"
+			configPath := writeFile(t, filepath.Join(os.Getenv("HOME"), "."+configFileName), config)
+			project := filepath.Join(root, "repo", "app")
+			writeFile(t, filepath.Join(project, "index.php"), "<?php\n")
+			if tt.pin != "" {
+				writeFile(t, filepath.Join(project, tt.pin, versionFile), "8.1\n")
+			}
+
+			args := append(append([]string{}, tt.args...), "current")
+			stdout, stderr, code := runRunner(t, project, []string{"PATH=" + bin, "PHP_RUNNER_CONFIG=" + configPath}, args...)
+			if code != 0 {
+				t.Fatalf("current exited %d: %s", code, stderr)
+			}
+			if got := strings.TrimSpace(stdout); got != tt.want {
+				t.Errorf("current = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendVersionToPath(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool // whether the bin dir should come first
+	}{
+		{name: "exec", args: []string{"--append-version-to-path"}, want: true},
+		{name: "supervised", args: []string{"--append-version-to-path", "--stderr-tail", "5"}, want: true},
+		{name: "without the flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			// The system php a PHP script would otherwise find
+			systemBin := filepath.Join(root, "usr", "bin")
+			writeStub(t, filepath.Join(systemBin, "php"), "exit 0")
+			binDir := filepath.Join(root, "versions", "8.2", "bin")
+			php := writeStub(t, filepath.Join(binDir, "php"), `echo "PATH=$PATH"; echo "php=$(command -v php)"`)
+			configPath := writeFile(t, filepath.Join(root, "conf", configFileName), "8.2: "+php+"\n")
+			pathEnv := systemBin + string(os.PathListSeparator) + os.Getenv("PATH")
+
+			args := append(append([]string{}, tt.args...), "x.php")
+			stdout, stderr, code := runRunner(t, root, []string{"PATH=" + pathEnv, "PHP_RUNNER_CONFIG=" + configPath}, args...)
+			if code != 0 {
+				t.Fatalf("exited %d: %s", code, stderr)
+			}
+			wantPath, wantPHP := pathEnv, filepath.Join(systemBin, "php")
+			if tt.want {
+				wantPath, wantPHP = binDir+string(os.PathListSeparator)+pathEnv, php
+			}
+			if !strings.Contains(stdout, "PATH="+wantPath+"\n") {
+				t.Errorf("child saw %q, want PATH=%s", stdout, wantPath)
+			}
+			if !strings.Contains(stdout, "php="+wantPHP+"\n") {
+				t.Errorf("child saw %q, want php=%s", stdout, wantPHP)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// versionsDirName is the directory next to the config holding one file per
+// version, so installers can each drop in their own without editing the
+// main config
+const versionsDirName = "versions.d"
+
+// readVersionsDir reads every <version>.yaml in dir and returns the entries
+// they amount to in the main config: "path: X" in 8.2.yaml becomes
+// "8.2: X" and any other "<setting>: value" becomes "<setting>.8.2: value".
+// A missing directory holds no versions.
+func readVersionsDir(dir string) ([]configEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var entries []configEntry
+	for _, path := range paths {
+		fileEntries, err := readVersionFileEntries(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+// readVersionFileEntries reads a single per-version file, checking each
+// setting so mistakes are reported against the file they are in
+func readVersionFileEntries(path string) ([]configEntry, error) {
+	version := strings.TrimSuffix(filepath.Base(path), ".yaml")
+	if _, ok := parseVersionParts(versionKeyRe.FindString(version)); !ok {
+		return nil, fmt.Errorf("file name %q is not a version such as 8.2.yaml", filepath.Base(path))
+	}
+	fileEntries, err := readConfigEntries(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []configEntry
+	hasPath := false
+	for _, entry := range fileEntries {
+		if entry.Key == "path" {
+			hasPath = true
+			// Relative paths are taken from the main config's directory
+			entries = append(entries, configEntry{Key: version, Value: entry.Value, Line: entry.Line, Base: filepath.Dir(filepath.Dir(path))})
+			continue
+		}
+		entry.Key += "." + version
+		if isSetting, err := applySetting(newConfig(), entry); err != nil {
+			return nil, err
+		} else if !isSetting {
+			return nil, fmt.Errorf("unknown setting %q on line %d", strings.TrimSuffix(entry.Key, "."+version), entry.Line)
+		}
+		entries = append(entries, entry)
+	}
+	if !hasPath {
+		return nil, fmt.Errorf("no path given for PHP %s", version)
+	}
+	return entries, nil
+}
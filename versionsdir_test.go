@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVersionsDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		main     string            // main config, with {{root}} for the temp root
+		files    map[string]string // versions.d files by name
+		want     map[string]string // configured paths, with {{root}}
+		wantArgs map[string][]string
+		wantEnv  map[string][]string
+		wantErr  string
+	}{
+		{
+			name: "several files merged",
+			main: "8.0: {{root}}/php8.0\n",
+			files: map[string]string{
+				"8.1.yaml":     "path: {{root}}/php8.1\nargs: -d memory_limit=-1\n",
+				"8.2.yaml":     "path: {{root}}/php8.2\nenv: APP_ENV=test\nenv: XDEBUG_MODE=off\n",
+				"8.3-zts.yaml": "# installed by the zts build\npath: ./php8.3-zts\n",
+				"notes.txt":    "ignored",
+			},
+			want: map[string]string{
+				"8.0":     "{{root}}/php8.0",
+				"8.1":     "{{root}}/php8.1",
+				"8.2":     "{{root}}/php8.2",
+				"8.3-zts": "{{root}}/php8.3-zts",
+			},
+			wantArgs: map[string][]string{"8.1": {"-d", "memory_limit=-1"}},
+			wantEnv:  map[string][]string{"8.2": {"APP_ENV=test", "XDEBUG_MODE=off"}},
+		},
+		{
+			name:     "main config wins",
+			main:     "8.1: {{root}}/php8.0\nargs.8.1: -n\n",
+			files:    map[string]string{"8.1.yaml": "path: {{root}}/php8.1\n"},
+			want:     map[string]string{"8.1": "{{root}}/php8.0"},
+			wantArgs: map[string][]string{"8.1": {"-n"}},
+		},
+		{
+			name:  "versions only in the directory",
+			files: map[string]string{"8.1.yaml": "path: {{root}}/php8.1\n", "8.2.yaml": "path: {{root}}/php8.2\n"},
+			want:  map[string]string{"8.1": "{{root}}/php8.1", "8.2": "{{root}}/php8.2"},
+		},
+		{
+			name:    "file name not a version",
+			files:   map[string]string{"latest.yaml": "path: {{root}}/php8.1\n"},
+			wantErr: `file name "latest.yaml" is not a version`,
+		},
+		{
+			name:    "no path",
+			files:   map[string]string{"8.1.yaml": "args: -n\n"},
+			wantErr: "8.1.yaml: no path given for PHP 8.1",
+		},
+		{
+			name:    "unknown setting",
+			files:   map[string]string{"8.1.yaml": "path: {{root}}/php8.1\ncolour: blue\n"},
+			wantErr: `8.1.yaml: unknown setting "colour" on line 2`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			for _, version := range []string{"8.0", "8.1", "8.2", "8.3-zts"} {
+				writeStub(t, filepath.Join(root, "php"+version), "exit 0")
+			}
+			replacer := strings.NewReplacer("{{root}}", root)
+			configPath := writeFile(t, filepath.Join(root, configFileName), replacer.Replace(tt.main))
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(root, versionsDirName, name), replacer.Replace(content))
+			}
+
+			config, err := loadConfig([]string{configPath})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(config.Versions) != len(tt.want) {
+				t.Errorf("versions = %v, want %v", config.Versions, tt.want)
+			}
+			for version, path := range tt.want {
+				if want := filepath.FromSlash(replacer.Replace(path)); config.Versions[version] != want {
+					t.Errorf("%s = %s, want %s", version, config.Versions[version], want)
+				}
+			}
+			for version, args := range tt.wantArgs {
+				if !slices.Equal(config.Args[version], args) {
+					t.Errorf("args.%s = %q, want %q", version, config.Args[version], args)
+				}
+			}
+			for version, env := range tt.wantEnv {
+				if !slices.Equal(config.Env[version], env) {
+					t.Errorf("env.%s = %q, want %q", version, config.Env[version], env)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values for --quiet-warnings-once
+const (
+	quietProcess = "process" // each warning once per run
+	quietPersist = "persist" // each warning once per warningWindow across runs
+)
+
+// warningWindow is how long a persisted warning stays quiet
+const warningWindow = time.Hour
+
+// warningStateName is the file next to the config that remembers recent
+// warnings for --quiet-warnings-once=persist
+const warningStateName = ".php-runner-warnings"
+
+// warned holds the warnings already printed by this process
+var warned = make(map[string]bool)
+
+// warnf prints a warning to stderr, unless --quiet-warnings-once is on and
+// the same warning has already been shown
+func warnf(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if opts.quietWarnings != "" {
+		if warned[message] {
+			return
+		}
+		warned[message] = true
+		if opts.quietWarnings == quietPersist && recentlyWarned(message) {
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+}
+
+// recentlyWarned reports whether message was printed by any run within the
+// last warningWindow, and records it as printed now if not. Each line of the
+// state file holds a Unix time and a hash of the message; stale lines are
+// dropped on every update. Runs racing to update it may drop each other's
+// entries, which only means a warning is shown again. Errors just mean the
+// warning is printed.
+func recentlyWarned(message string) bool {
+	warningState := stateFile(warningStateName)
+	if warningState == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(message))
+	key := hex.EncodeToString(sum[:8])
+	now := time.Now()
+
+	var kept []string
+	if file, err := os.Open(warningState); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			stamp, lineKey, ok := strings.Cut(scanner.Text(), " ")
+			seconds, err := strconv.ParseInt(stamp, 10, 64)
+			if !ok || err != nil || now.Sub(time.Unix(seconds, 0)) >= warningWindow {
+				continue
+			}
+			if lineKey == key {
+				file.Close()
+				return true
+			}
+			kept = append(kept, scanner.Text())
+		}
+		file.Close()
+	}
+
+	kept = append(kept, fmt.Sprintf("%d %s", now.Unix(), key))
+	writeFileAtomic(warningState, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+	return false
+}
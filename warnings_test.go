@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuietWarningsOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		quiet string
+		runs  int // simulated php-runner runs, each warning three times
+		want  int // times each warning is printed in all
+	}{
+		{name: "off", runs: 1, want: 3},
+		{name: "process", quiet: quietProcess, runs: 1, want: 1},
+		{name: "process across runs", quiet: quietProcess, runs: 2, want: 2},
+		{name: "persist across runs", quiet: quietPersist, runs: 2, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			configDir = t.TempDir()
+			opts.quietWarnings = tt.quiet
+			savedWarned := warned
+			t.Cleanup(func() { warned = savedWarned })
+
+			var output string
+			for run := 0; run < tt.runs; run++ {
+				warned = make(map[string]bool)
+				output += captureStderr(t, func() {
+					for i := 0; i < 3; i++ {
+						warnf("PHP executable not found at %s (line %d)", "/opt/php8.1", 1)
+						warnf("PHP executable not found at %s (line %d)", "/opt/php8.2", 2)
+					}
+				})
+			}
+			for _, warning := range []string{
+				"Warning: PHP executable not found at /opt/php8.1 (line 1)\n",
+				"Warning: PHP executable not found at /opt/php8.2 (line 2)\n",
+			} {
+				if got := strings.Count(output, warning); got != tt.want {
+					t.Errorf("%q printed %d times, want %d", warning, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveWorkspaceMin(t *testing.T) {
+	tests := []struct {
+		name     string
+		boundary string
+		files    map[string]string // composer.json requirements by directory, relative to the temp dir
+		start    string
+		want     string
+		wantOK   bool
+	}{
+		{
+			name:   "nested packages narrow the range",
+			files:  map[string]string{"repo": "^8.1", "repo/packages/a": "^8.2", "repo/packages/b": ">=8.1"},
+			start:  "repo/packages/b",
+			want:   "8.2",
+			wantOK: true,
+		},
+		{
+			name:   "manifest above the repository ignored",
+			files:  map[string]string{".": "^8.3", "repo": "^8.1", "repo/app": ">=8.1"},
+			start:  "repo/app",
+			want:   "8.1",
+			wantOK: true,
+		},
+		{
+			name:     "manifest above the repository counted without a boundary",
+			boundary: boundaryNone,
+			files:    map[string]string{".": "^8.3", "repo": "^8.1", "repo/app": ">=8.1"},
+			start:    "repo/app",
+			want:     "8.3",
+			wantOK:   true,
+		},
+		{
+			name:  "no requirements",
+			files: map[string]string{"repo": ""},
+			start: "repo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			root := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(root, "repo", ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for dir, requirement := range tt.files {
+				manifest := `{"require": {}}`
+				if requirement != "" {
+					manifest = `{"require": {"php": "` + requirement + `"}}`
+				}
+				writeFile(t, filepath.Join(root, dir, "composer.json"), manifest)
+			}
+			config := newConfig()
+			config.Boundary = tt.boundary
+			for _, version := range []string{"7.4", "8.1", "8.2", "8.3"} {
+				config.Versions[version] = "/usr/bin/php" + version
+			}
+
+			resolution, ok, err := resolveWorkspaceMin(filepath.Join(root, tt.start), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK || resolution.Version != tt.want {
+				t.Errorf("resolveWorkspaceMin = %q, %v, want %q, %v", resolution.Version, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
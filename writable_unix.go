@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// dirWritable reports whether the current user may create files in dir
+func dirWritable(dir string) bool {
+	const wOK = 0x2 // W_OK from unistd.h
+	return syscall.Access(dir, wOK) == nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// structuredConfigRe finds the top-level versions: key that marks a config
+// written as nested YAML rather than flat "key: value" lines
+var structuredConfigRe = regexp.MustCompile(`(?m)^versions\s*:`)
+
+// isStructuredConfig reports whether content is a nested YAML config such as
+//
+//	versions:
+//	  8.2: /usr/bin/php8.2
+//	default: 8.2
+//	aliases:
+//	  stable: 8.2
+func isStructuredConfig(content []byte) bool {
+	return structuredConfigRe.Match(content)
+}
+
+// yamlValue is a config value as written: a scalar, a list of scalars or a
+// map of further values. Scalars are kept as text, so a key such as 8.10
+// isn't read as the number 8.1.
+type yamlValue struct {
+	Scalar *string
+	List   []string
+	Fields map[string]yamlValue
+}
+
+func (v *yamlValue) UnmarshalYAML(unmarshal func(any) error) error {
+	var scalar string
+	if err := unmarshal(&scalar); err == nil {
+		v.Scalar = &scalar
+		return nil
+	}
+	if err := unmarshal(&v.List); err == nil {
+		return nil
+	}
+	return unmarshal(&v.Fields)
+}
+
+// parseStructuredConfig turns a nested YAML config into the entries the flat
+// format would have: each version under versions: becomes "<version>: path",
+// a map such as ini_scan_dir: becomes "ini_scan_dir.<version>" entries, and
+// aliases: becomes "alias.<name>" entries. Settings keep their flat names.
+func parseStructuredConfig(content []byte) ([]configEntry, error) {
+	var doc map[string]yamlValue
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	lines := lastLines(yamlKeyLines(content))
+	lineOf := func(parent, key string) int {
+		if line, ok := lines[parent+"."+key]; ok {
+			return line
+		}
+		return lines[parent]
+	}
+
+	var entries []configEntry
+	add := func(key string, value yamlValue, line int) error {
+		setting, _, _ := strings.Cut(key, ".")
+		switch {
+		case value.Scalar != nil && *value.Scalar != "":
+			entries = append(entries, configEntry{Key: key, Value: *value.Scalar, Line: line})
+		case len(value.List) > 0 && repeatableSettings[setting]:
+			for _, item := range value.List {
+				entries = append(entries, configEntry{Key: key, Value: item, Line: line})
+			}
+		case len(value.List) > 0:
+			entries = append(entries, configEntry{Key: key, Value: strings.Join(value.List, ", "), Line: line})
+		case value.Fields != nil:
+			return fmt.Errorf("unexpected nested map for %s on line %d", key, line)
+		default:
+			return fmt.Errorf("empty value for %s on line %d", key, line)
+		}
+		return nil
+	}
+
+	for _, key := range sortedByLine(doc, "", lines) {
+		value := doc[key]
+		switch {
+		case key == "versions":
+			if value.Scalar != nil || value.List != nil {
+				return nil, fmt.Errorf("versions on line %d must map versions to paths", lines[key])
+			}
+			for _, version := range sortedByLine(value.Fields, key, lines) {
+				path := value.Fields[version]
+				if path.Scalar == nil || *path.Scalar == "" {
+					return nil, fmt.Errorf("empty path for version %s on line %d", version, lineOf(key, version))
+				}
+				entries = append(entries, configEntry{Key: version, Value: *path.Scalar, Line: lineOf(key, version)})
+			}
+		case value.Fields != nil:
+			setting := key
+			if key == "aliases" {
+				setting = "alias"
+			}
+			for _, scope := range sortedByLine(value.Fields, key, lines) {
+				if err := add(setting+"."+scope, value.Fields[scope], lineOf(key, scope)); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			if err := add(key, value, lines[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+// sortedByLine returns the keys of fields in the order they appear in the
+// file, given the lines of the keys under parent
+func sortedByLine(fields map[string]yamlValue, parent string, lines map[string]int) []string {
+	prefix := ""
+	if parent != "" {
+		prefix = parent + "."
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if lines[prefix+keys[i]] != lines[prefix+keys[j]] {
+			return lines[prefix+keys[i]] < lines[prefix+keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// yamlKeyLines maps each top-level key, and each "<top>.<key>" nested one
+// level below it, to the lines it is on, for error messages. Keys written in
+// flow style ({8.2: ...}) aren't found; they are reported at their parent.
+func yamlKeyLines(content []byte) map[string][]int {
+	lines := make(map[string][]int)
+	parent := ""
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		key, _, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		if line[0] != ' ' && line[0] != '\t' {
+			parent = key
+			lines[key] = append(lines[key], i+1)
+		} else if parent != "" {
+			lines[parent+"."+key] = append(lines[parent+"."+key], i+1)
+		}
+	}
+	return lines
+}
+
+// lastLines keeps the last line of each key, which is the one whose value
+// the YAML decoder keeps
+func lastLines(lines map[string][]int) map[string]int {
+	last := make(map[string]int, len(lines))
+	for key, keyLines := range lines {
+		last[key] = keyLines[len(keyLines)-1]
+	}
+	return last
+}
+
+// findStructuredDuplicates returns the keys of a nested YAML config that
+// appear more than once under the same parent, in order of first
+// appearance, named as the flat format would name them. Unlike flat lines, a
+// repeated YAML key replaces the earlier one even for repeatable settings,
+// so those are reported too.
+func findStructuredDuplicates(content []byte) []duplicateEntry {
+	var duplicates []duplicateEntry
+	for key, keyLines := range yamlKeyLines(content) {
+		if len(keyLines) < 2 {
+			continue
+		}
+		parent, child, nested := strings.Cut(key, ".")
+		switch {
+		case nested && parent == "versions":
+			key = child
+		case nested && parent == "aliases":
+			key = "alias." + child
+		}
+		duplicates = append(duplicates, duplicateEntry{Key: key, Lines: keyLines})
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Lines[0] < duplicates[j].Lines[0] })
+	return duplicates
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindStructuredDuplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []duplicateEntry
+	}{
+		{
+			name:    "none",
+			content: "versions:\n  8.1: /usr/bin/php8.1\n  8.2: /usr/bin/php8.2\ndefault: 8.2\n",
+		},
+		{
+			name:    "version",
+			content: "versions:\n  8.2: /usr/bin/php8.2\n  8.1: /usr/bin/php8.1\n  8.2: /opt/php8.2\n",
+			want:    []duplicateEntry{{Key: "8.2", Lines: []int{2, 4}}},
+		},
+		{
+			name:    "alias and setting",
+			content: "versions:\n  8.2: /usr/bin/php8.2\ndefault: 8.1\naliases:\n  stable: 8.1\n  stable: 8.2\ndefault: 8.2\n",
+			want:    []duplicateEntry{{Key: "default", Lines: []int{3, 7}}, {Key: "alias.stable", Lines: []int{5, 6}}},
+		},
+		{
+			name:    "per-version setting",
+			content: "versions:\n  8.2: /usr/bin/php8.2\nini_scan_dir:\n  8.2: /etc/a\n  # comment\n  8.2: /etc/b\n",
+			want:    []duplicateEntry{{Key: "ini_scan_dir.8.2", Lines: []int{4, 6}}},
+		},
+		{
+			name:    "same key under different parents",
+			content: "versions:\n  8.2: /usr/bin/php8.2\nini_scan_dir:\n  8.2: /etc/a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findStructuredDuplicates([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("duplicates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructuredConfigKeepsLastDuplicate(t *testing.T) {
+	entries, err := parseStructuredConfig([]byte("versions:\n  8.2: /usr/bin/php8.2\n  8.2: /opt/php8.2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []configEntry{{Key: "8.2", Value: "/opt/php8.2", Line: 3}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+}